@@ -0,0 +1,3 @@
+ALTER TABLE couriers
+DROP COLUMN active,
+DROP COLUMN archived;
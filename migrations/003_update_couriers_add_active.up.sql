@@ -0,0 +1,3 @@
+ALTER TABLE couriers
+    ADD active boolean not null default true,
+    ADD archived boolean not null default false;
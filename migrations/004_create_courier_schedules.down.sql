@@ -0,0 +1,3 @@
+DROP TABLE courier_schedule_overrides;
+
+DROP TABLE courier_schedules;
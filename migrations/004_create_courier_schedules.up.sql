@@ -0,0 +1,19 @@
+CREATE TABLE courier_schedules
+(
+    courier_id int not null,
+    weekday smallint not null, --ISO weekday, 1 is Monday
+    working_hours varchar(11)[] not null,
+    unique (courier_id, weekday)
+);
+
+CREATE TABLE courier_schedule_overrides
+(
+    id serial not null unique,
+    courier_id int not null,
+    override_date date not null,
+    kind varchar(11) not null, --DAY_OFF, SICK_DAY or EXTRA_SHIFT
+    working_hours varchar(11)[] not null,
+    comment varchar
+);
+
+CREATE INDEX courier_schedule_overrides_courier_date_idx ON courier_schedule_overrides (courier_id, override_date);
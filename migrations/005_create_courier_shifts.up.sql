@@ -0,0 +1,9 @@
+CREATE TABLE courier_shifts
+(
+    id serial not null unique,
+    courier_id int not null,
+    started_at timestamptz not null,
+    ended_at timestamptz
+);
+
+CREATE UNIQUE INDEX courier_shifts_open_idx ON courier_shifts (courier_id) WHERE ended_at IS NULL;
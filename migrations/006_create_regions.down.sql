@@ -0,0 +1,13 @@
+DROP TRIGGER regions_couriers_fkey ON regions;
+
+DROP FUNCTION check_region_not_referenced();
+
+DROP TRIGGER couriers_regions_fkey ON couriers;
+
+DROP FUNCTION check_courier_regions();
+
+ALTER TABLE orders
+    DROP CONSTRAINT orders_regions_fkey,
+    ALTER COLUMN regions TYPE varchar USING regions::varchar;
+
+DROP TABLE regions;
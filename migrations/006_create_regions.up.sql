@@ -0,0 +1,49 @@
+CREATE TABLE regions
+(
+    id integer not null primary key,
+    name varchar not null,
+    time_zone varchar not null default 'UTC',
+    active boolean not null default true
+);
+
+INSERT INTO regions(id, name)
+SELECT region, 'Region ' || region
+FROM (SELECT unnest(regions) AS region FROM couriers
+      UNION
+      SELECT regions::integer FROM orders) existing_regions;
+
+ALTER TABLE orders
+    ALTER COLUMN regions TYPE integer USING regions::integer,
+    ADD CONSTRAINT orders_regions_fkey FOREIGN KEY (regions) REFERENCES regions (id);
+
+CREATE FUNCTION check_courier_regions() RETURNS trigger AS
+$$
+BEGIN
+    IF EXISTS(SELECT 1 FROM unnest(NEW.regions) AS region WHERE region NOT IN (SELECT id FROM regions)) THEN
+        RAISE foreign_key_violation USING MESSAGE = 'courier references unknown region';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER couriers_regions_fkey
+    BEFORE INSERT OR UPDATE OF regions
+    ON couriers
+    FOR EACH ROW
+EXECUTE FUNCTION check_courier_regions();
+
+CREATE FUNCTION check_region_not_referenced() RETURNS trigger AS
+$$
+BEGIN
+    IF EXISTS(SELECT 1 FROM couriers WHERE OLD.id = ANY (regions)) THEN
+        RAISE foreign_key_violation USING MESSAGE = 'region is referenced by couriers';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER regions_couriers_fkey
+    BEFORE DELETE
+    ON regions
+    FOR EACH ROW
+EXECUTE FUNCTION check_region_not_referenced();
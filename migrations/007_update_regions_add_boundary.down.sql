@@ -0,0 +1,6 @@
+ALTER TABLE orders
+DROP COLUMN lat,
+DROP COLUMN lon;
+
+ALTER TABLE regions
+DROP COLUMN boundary;
@@ -0,0 +1,6 @@
+ALTER TABLE regions
+    ADD boundary jsonb; --GeoJSON Polygon or MultiPolygon
+
+ALTER TABLE orders
+    ADD lat double precision,
+    ADD lon double precision;
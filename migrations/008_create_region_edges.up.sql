@@ -0,0 +1,8 @@
+CREATE TABLE region_edges
+(
+    from_region integer not null references regions (id) on delete cascade,
+    to_region integer not null references regions (id) on delete cascade,
+    courier_type varchar(4) not null,
+    minutes integer not null check (minutes >= 0),
+    unique (from_region, to_region, courier_type)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE orders
+DROP COLUMN group_order_id,
+DROP COLUMN planned_arrival;
+
+DROP TABLE group_orders;
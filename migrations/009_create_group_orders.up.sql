@@ -0,0 +1,16 @@
+CREATE TABLE group_orders
+(
+    id serial not null unique,
+    courier_id int not null,
+    assignment_date date not null,
+    start_minute int not null,
+    finish_minute int not null,
+    strategy varchar not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX group_orders_date_idx ON group_orders (assignment_date, courier_id);
+
+ALTER TABLE orders
+    ADD group_order_id int references group_orders (id) on delete set null,
+    ADD planned_arrival varchar(5);
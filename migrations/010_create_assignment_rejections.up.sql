@@ -0,0 +1,9 @@
+CREATE TABLE assignment_rejections
+(
+    order_id int not null primary key references orders (order_id) on delete cascade,
+    assignment_date date not null,
+    strategy varchar not null,
+    reason varchar not null,
+    nearest_misses jsonb not null default '[]',
+    created_at timestamptz not null default now()
+);
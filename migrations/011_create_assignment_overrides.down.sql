@@ -0,0 +1,4 @@
+DROP TABLE assignment_overrides;
+
+ALTER TABLE orders
+DROP COLUMN pinned;
@@ -0,0 +1,16 @@
+ALTER TABLE orders
+    ADD pinned boolean not null default false;
+
+CREATE TABLE assignment_overrides
+(
+    id serial not null unique,
+    order_id int not null references orders (order_id) on delete cascade,
+    courier_id int not null,
+    previous_courier_id int,
+    group_order_id int not null,
+    assignment_date date not null,
+    forced boolean not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX assignment_overrides_order_idx ON assignment_overrides (order_id);
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+DROP COLUMN priority,
+DROP COLUMN promised_by,
+DROP COLUMN lateness_minutes;
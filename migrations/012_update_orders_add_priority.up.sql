@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ADD priority int not null default 0,
+    ADD promised_by timestamptz,
+    ADD lateness_minutes int;
@@ -0,0 +1,4 @@
+DROP INDEX orders_delivery_date_idx;
+
+ALTER TABLE orders
+DROP COLUMN delivery_date;
@@ -0,0 +1,4 @@
+ALTER TABLE orders
+    ADD delivery_date date not null default current_date;
+
+CREATE INDEX orders_delivery_date_idx ON orders (delivery_date);
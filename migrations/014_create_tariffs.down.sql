@@ -0,0 +1,6 @@
+ALTER TABLE orders
+DROP COLUMN tariff_version;
+
+DROP TABLE tariff_surges;
+DROP TABLE tariff_zone_prices;
+DROP TABLE tariffs;
@@ -0,0 +1,27 @@
+CREATE TABLE tariffs
+(
+    version serial primary key,
+    base_price int not null,
+    per_kg_price int not null,
+    narrow_window_minutes int not null,
+    narrow_window_surcharge int not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE TABLE tariff_zone_prices
+(
+    tariff_version int not null references tariffs (version) on delete cascade,
+    region_id int not null,
+    base_price int not null,
+    primary key (tariff_version, region_id)
+);
+
+CREATE TABLE tariff_surges
+(
+    tariff_version int not null references tariffs (version) on delete cascade,
+    time_interval varchar(11) not null,
+    multiplier double precision not null
+);
+
+ALTER TABLE orders
+    ADD tariff_version int references tariffs (version);
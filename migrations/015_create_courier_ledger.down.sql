@@ -0,0 +1,2 @@
+DROP TABLE courier_ledger;
+DROP TABLE payout_periods;
@@ -0,0 +1,24 @@
+CREATE TABLE payout_periods
+(
+    id serial primary key,
+    period_from date not null,
+    period_to date not null,
+    closed_at timestamptz not null default now()
+);
+
+CREATE TABLE courier_ledger
+(
+    id serial primary key,
+    courier_id int not null,
+    kind varchar(16) not null,
+    reason varchar(32) not null default '',
+    amount bigint not null,
+    order_id int references orders (order_id),
+    comment varchar,
+    entry_date date not null,
+    period_id int references payout_periods (id),
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX courier_ledger_courier_idx ON courier_ledger (courier_id, entry_date);
+CREATE UNIQUE INDEX courier_ledger_order_idx ON courier_ledger (order_id, kind, reason) WHERE order_id IS NOT NULL;
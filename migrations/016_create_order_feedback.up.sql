@@ -0,0 +1,10 @@
+CREATE TABLE order_feedback
+(
+    order_id int primary key references orders (order_id),
+    courier_id int not null,
+    score int not null check (score between 1 and 5),
+    comment varchar,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX order_feedback_courier_idx ON order_feedback (courier_id);
@@ -0,0 +1,2 @@
+DROP TABLE order_events;
+DROP FUNCTION order_events_append_only;
@@ -0,0 +1,24 @@
+CREATE TABLE order_events
+(
+    id bigserial primary key,
+    order_id int not null references orders (order_id),
+    event_type varchar(32) not null,
+    actor varchar not null,
+    payload jsonb not null default '{}',
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX order_events_order_idx ON order_events (order_id, id);
+
+CREATE FUNCTION order_events_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'order_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_events_append_only
+    BEFORE UPDATE OR DELETE
+    ON order_events
+    FOR EACH ROW
+EXECUTE FUNCTION order_events_append_only();
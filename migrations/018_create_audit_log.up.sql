@@ -0,0 +1,20 @@
+CREATE TABLE audit_log
+(
+    id bigserial primary key,
+    actor varchar not null,
+    method varchar(8) not null,
+    route varchar not null,
+    entity_ids jsonb not null default '{}',
+    entity_type varchar(32),
+    entity_id bigint,
+    before jsonb,
+    after jsonb,
+    diff jsonb,
+    request_id varchar not null,
+    status int not null,
+    created_at timestamptz not null default now()
+);
+
+CREATE INDEX audit_log_created_idx ON audit_log (created_at);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, created_at);
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
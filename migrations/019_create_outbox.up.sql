@@ -0,0 +1,14 @@
+CREATE TABLE outbox
+(
+    id bigserial primary key,
+    event_type varchar(32) not null,
+    aggregate_type varchar(32) not null,
+    aggregate_id bigint not null,
+    payload jsonb not null default '{}',
+    created_at timestamptz not null default now(),
+    published_at timestamptz,
+    attempts int not null default 0,
+    last_error varchar
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,29 @@
+CREATE TABLE webhooks
+(
+    id serial primary key,
+    url varchar not null,
+    event_types varchar[] not null,
+    secret varchar not null,
+    active boolean not null default true,
+    created_at timestamptz not null default now()
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id bigserial primary key,
+    webhook_id int not null references webhooks (id) on delete cascade,
+    event_id bigint not null,
+    event_type varchar(32) not null,
+    body jsonb not null,
+    status varchar(16) not null default 'pending',
+    attempts int not null default 0,
+    next_attempt_at timestamptz not null default now(),
+    last_status_code int,
+    last_error varchar,
+    created_at timestamptz not null default now(),
+    delivered_at timestamptz,
+    unique (webhook_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id);
@@ -0,0 +1,2 @@
+DROP TRIGGER outbox_notify ON outbox;
+DROP FUNCTION notify_outbox_event;
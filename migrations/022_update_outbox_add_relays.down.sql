@@ -0,0 +1,17 @@
+ALTER TABLE outbox
+    ADD published_at timestamptz,
+    ADD attempts int not null default 0,
+    ADD last_error varchar;
+
+UPDATE outbox SET published_at = now()
+WHERE id <= (SELECT last_event_id FROM outbox_relays WHERE name = 'publisher');
+
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE published_at IS NULL;
+
+DROP TABLE outbox_failures;
+DROP TABLE outbox_relays;
+
+DROP INDEX outbox_order_idx;
+
+ALTER TABLE outbox
+DROP COLUMN tx_id;
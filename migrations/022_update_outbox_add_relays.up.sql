@@ -0,0 +1,40 @@
+-- events are published in the order of the transactions that wrote them, an event is published only
+-- once every older transaction has finished, so an event committed late cannot be skipped
+ALTER TABLE outbox
+    ADD tx_id xid8 not null default pg_current_xact_id();
+
+CREATE INDEX outbox_order_idx ON outbox (tx_id, id);
+
+CREATE TABLE outbox_relays
+(
+    name varchar(32) primary key,
+    last_tx_id xid8 not null default '0',
+    last_event_id bigint not null default 0,
+    attempts int not null default 0,
+    last_error varchar,
+    leased_until timestamptz
+);
+
+CREATE TABLE outbox_failures
+(
+    id bigserial primary key,
+    relay varchar(32) not null,
+    event_id bigint not null,
+    attempts int not null,
+    last_error varchar not null,
+    created_at timestamptz not null default now()
+);
+
+-- the relay continues with the first event it has not published yet, existing events share one tx_id
+INSERT INTO outbox_relays(name, last_tx_id, last_event_id)
+SELECT 'publisher', coalesce((SELECT tx_id FROM outbox LIMIT 1), '0'), coalesce(
+    (SELECT min(id) - 1 FROM outbox WHERE published_at IS NULL),
+    (SELECT max(id) FROM outbox),
+    0);
+
+DROP INDEX outbox_pending_idx;
+
+ALTER TABLE outbox
+DROP COLUMN published_at,
+DROP COLUMN attempts,
+DROP COLUMN last_error;
@@ -0,0 +1 @@
+DELETE FROM outbox_relays WHERE name = 'webhooks';
@@ -0,0 +1,4 @@
+-- webhooks were enqueued together with the configured publisher, they continue where it is
+INSERT INTO outbox_relays(name, last_tx_id, last_event_id, attempts, last_error)
+SELECT 'webhooks', last_tx_id, last_event_id, attempts, last_error FROM outbox_relays WHERE name = 'publisher'
+ON CONFLICT (name) DO NOTHING;
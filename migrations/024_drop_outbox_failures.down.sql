@@ -0,0 +1,9 @@
+CREATE TABLE outbox_failures
+(
+    id bigserial primary key,
+    relay varchar(32) not null,
+    event_id bigint not null,
+    attempts int not null,
+    last_error varchar not null,
+    created_at timestamptz not null default now()
+);
@@ -0,0 +1,2 @@
+-- relays retry a failed event until it is published instead of giving it up
+DROP TABLE outbox_failures;
@@ -0,0 +1,148 @@
+package assignment
+
+import (
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/time_interval"
+	"fmt"
+	"sort"
+)
+
+const (
+	StrategyGreedyCost     = "greedy_cost"
+	StrategyGreedyDeadline = "greedy_deadline"
+	StrategyFairLoad       = "fair_load"
+	StrategyLocalSearch    = "local_search"
+)
+
+// Courier is a courier available for assignment with its working hours for the assignment date.
+type Courier struct {
+	CourierId    int64
+	CourierType  string
+	Regions      []int64
+	WorkingHours []time_interval.Interval
+	// Baseline is the average daily historical earnings of the courier, used by the earnings fairness objective.
+	Baseline int64
+}
+
+type Order struct {
+	OrderId       int64
+	Weight        float64
+	Region        int64
+	DeliveryHours []time_interval.Interval
+	Cost          int64
+	// Priority orders are placed before lower ones by every strategy
+	Priority int
+	// PromisedBy is the promised delivery minute on the assignment date, nil if nothing was promised for the date
+	PromisedBy *int
+}
+
+// Group is a group order: orders delivered by a courier in one trip, in delivery order.
+// Start and Finish are minutes since midnight, Arrivals holds the planned delivery minute of every order.
+type Group struct {
+	GroupOrderId int64
+	Orders       []Order
+	Arrivals     []int
+	Start        int
+	Finish       int
+	// Fixed groups come from an earlier plan and are kept as they are.
+	Fixed bool
+}
+
+type CourierPlan struct {
+	Courier Courier
+	Groups  []*Group
+}
+
+// Problem is the input of an assignment run. Fixed holds groups per courier id that must be kept as they are,
+// Pinned holds groups with pinned orders that must stay with the courier but can take more orders.
+// Fairness is an optional objective every strategy places orders by before its own preferences.
+// Pricing gives the effective cost of orders in groups the plans are scored by.
+type Problem struct {
+	Couriers []Courier
+	Orders   []Order
+	Fixed    map[int64][]*Group
+	Pinned   map[int64][]*Group
+	Fairness string
+	Pricing  pricing.Rules
+}
+
+// Result has a plan for every courier of the problem, sorted by courier id,
+// and a rejection for every unassigned order.
+type Result struct {
+	Plans      []*CourierPlan
+	Unassigned []Order
+	Rejections []Rejection
+	pricing    pricing.Rules
+}
+
+// Assigner distributes orders between couriers.
+type Assigner interface {
+	Name() string
+	Assign(problem Problem) *Result
+}
+
+func Strategies() []string {
+	return []string{StrategyGreedyCost, StrategyGreedyDeadline, StrategyFairLoad, StrategyLocalSearch}
+}
+
+// NewAssigner returns the assigner implementing the strategy.
+func NewAssigner(strategy string, planner Planner) (Assigner, error) {
+	switch strategy {
+	case StrategyGreedyCost:
+		return NewGreedyByCost(planner), nil
+	case StrategyGreedyDeadline:
+		return NewGreedyByDeadline(planner), nil
+	case StrategyFairLoad:
+		return NewFairLoad(planner), nil
+	case StrategyLocalSearch:
+		return NewLocalSearch(planner), nil
+	}
+	return nil, fmt.Errorf("unknown assignment strategy '%s', expected one of %v", strategy, Strategies())
+}
+
+func sortedOrders(orders []Order, less func(a, b Order) bool) []Order {
+	result := make([]Order, len(orders))
+	copy(result, orders)
+	sort.SliceStable(result, func(a, b int) bool {
+		if less(result[a], result[b]) {
+			return true
+		}
+		if less(result[b], result[a]) {
+			return false
+		}
+		return result[a].OrderId < result[b].OrderId
+	})
+	return result
+}
+
+// deadline is the end of the latest delivery window or the promised minute if it is earlier.
+func deadline(order Order) int {
+	end := 24 * 60
+	for i, window := range order.DeliveryHours {
+		if i == 0 || window.End > end {
+			end = window.End
+		}
+	}
+	if order.PromisedBy != nil && *order.PromisedBy < end {
+		end = *order.PromisedBy
+	}
+	return end
+}
+
+// byUrgency keeps the order of the strategy but moves higher priorities first
+// and, within a priority, orders with an earlier promised time before the others.
+func byUrgency(orders []Order) []Order {
+	result := make([]Order, len(orders))
+	copy(result, orders)
+	sort.SliceStable(result, func(a, b int) bool {
+		if result[a].Priority != result[b].Priority {
+			return result[a].Priority > result[b].Priority
+		}
+		promisedA, promisedB := result[a].PromisedBy, result[b].PromisedBy
+		if promisedA == nil || promisedB == nil {
+			return promisedA != nil && promisedB == nil
+		}
+		return *promisedA < *promisedB
+	})
+	return result
+}
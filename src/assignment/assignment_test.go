@@ -0,0 +1,182 @@
+package assignment
+
+import (
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/routing"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/time_interval"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func hours(t *testing.T, strs ...string) []time_interval.Interval {
+	intervals, err := time_interval.ParseAll(strs)
+	require.NoError(t, err)
+	return intervals
+}
+
+func testPlanner() Planner {
+	return NewPlanner(routing.NewSequencer(routing.NewEstimator(routing.NewGraph())))
+}
+
+func testProblem(t *testing.T) Problem {
+	return Problem{
+		Couriers: []Courier{
+			{CourierId: 1, CourierType: model.CourierTypeFoot, Regions: []int64{1}, WorkingHours: hours(t, "09:00-10:00")},
+			{CourierId: 2, CourierType: model.CourierTypeAuto, Regions: []int64{1, 2}, WorkingHours: hours(t, "09:00-11:00")},
+		},
+		Orders: []Order{
+			{OrderId: 1, Weight: 5, Region: 1, DeliveryHours: hours(t, "09:00-12:00"), Cost: 100},
+			{OrderId: 2, Weight: 30, Region: 2, DeliveryHours: hours(t, "09:00-12:00"), Cost: 300},
+			{OrderId: 3, Weight: 3, Region: 1, DeliveryHours: hours(t, "09:00-09:20"), Cost: 50},
+			{OrderId: 4, Weight: 1, Region: 3, DeliveryHours: hours(t, "09:00-12:00"), Cost: 500},
+		},
+	}
+}
+
+func TestStrategiesRespectConstraints(t *testing.T) {
+	for _, strategy := range Strategies() {
+		assigner, err := NewAssigner(strategy, testPlanner())
+		require.NoError(t, err)
+		require.Equal(t, strategy, assigner.Name())
+
+		result := assigner.Assign(testProblem(t))
+		require.Len(t, result.Plans, 2, strategy)
+
+		unassigned := make([]int64, 0)
+		for _, order := range result.Unassigned {
+			unassigned = append(unassigned, order.OrderId)
+		}
+		require.Contains(t, unassigned, int64(4), "%s: nobody works in region 3", strategy)
+
+		for _, plan := range result.Plans {
+			profile := model.CourierTypeProfiles[plan.Courier.CourierType]
+			for _, group := range plan.Groups {
+				require.LessOrEqual(t, len(group.Orders), profile.MaxOrders, strategy)
+				for i, order := range group.Orders {
+					require.Contains(t, plan.Courier.Regions, order.Region, strategy)
+					require.LessOrEqual(t, group.Arrivals[i], deadline(order), strategy)
+				}
+				require.GreaterOrEqual(t, group.Start, plan.Courier.WorkingHours[0].Start, strategy)
+				require.LessOrEqual(t, group.Finish, plan.Courier.WorkingHours[0].End, strategy)
+			}
+		}
+	}
+}
+
+func TestGreedyByCost(t *testing.T) {
+	result := NewGreedyByCost(testPlanner()).Assign(testProblem(t))
+
+	score := Evaluate(result)
+	require.Equal(t, int64(450), score.DeliveredCost)
+	require.Equal(t, 3, score.Assigned)
+	require.Equal(t, 1, score.Unassigned)
+	require.Greater(t, score.Utilization, 0.0)
+}
+
+func TestFixedGroupsAreKept(t *testing.T) {
+	problem := testProblem(t)
+	problem.Fixed = map[int64][]*Group{
+		1: {{GroupOrderId: 7, Orders: []Order{{OrderId: 9, Weight: 1, Region: 1, Cost: 10}}, Start: 540, Finish: 600}},
+	}
+
+	result := NewGreedyByCost(testPlanner()).Assign(problem)
+	foot := result.Plans[0]
+	require.Len(t, foot.Groups, 1, "foot courier is busy for the whole window")
+	require.Equal(t, int64(7), foot.Groups[0].GroupOrderId)
+	require.True(t, foot.Groups[0].Fixed)
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	_, err := NewAssigner("random", testPlanner())
+	require.Error(t, err)
+}
+
+func TestRejectionReasons(t *testing.T) {
+	problem := testProblem(t)
+	problem.Orders = append(problem.Orders,
+		Order{OrderId: 5, Weight: 50, Region: 2, DeliveryHours: hours(t, "09:00-12:00"), Cost: 10},
+		Order{OrderId: 6, Weight: 1, Region: 1, DeliveryHours: hours(t, "20:00-21:00"), Cost: 10},
+	)
+
+	result := NewGreedyByCost(testPlanner()).Assign(problem)
+	reasons := make(map[int64]Rejection)
+	for _, rejection := range result.Rejections {
+		reasons[rejection.OrderId] = rejection
+	}
+
+	require.Equal(t, ReasonNoCourierForRegion, reasons[4].Reason)
+	require.Empty(t, reasons[4].NearestMisses)
+	require.Equal(t, ReasonOverWeight, reasons[5].Reason)
+	require.Equal(t, []Miss{{CourierId: 2, Reason: ReasonOverWeight}}, reasons[5].NearestMisses)
+	require.Equal(t, ReasonNoTimeWindowOverlap, reasons[6].Reason)
+	require.Len(t, reasons[6].NearestMisses, 2)
+}
+
+func TestPinnedGroupsAreKeptAndExtended(t *testing.T) {
+	problem := testProblem(t)
+	pinned := Order{OrderId: 9, Weight: 1, Region: 2, DeliveryHours: hours(t, "09:00-12:00"), Cost: 10}
+	problem.Pinned = map[int64][]*Group{
+		2: {{GroupOrderId: 7, Orders: []Order{pinned}, Arrivals: []int{548}, Start: 540, Finish: 548}},
+	}
+
+	result := NewGreedyByCost(testPlanner()).Assign(problem)
+	auto := result.Plans[1]
+	var kept *Group
+	for _, group := range auto.Groups {
+		if group.GroupOrderId == 7 {
+			kept = group
+		}
+	}
+	require.NotNil(t, kept, "pinned group must stay with the courier")
+	require.False(t, kept.Fixed)
+	require.Greater(t, len(kept.Orders), 1, "pinned group can take more orders")
+}
+
+func TestInsert(t *testing.T) {
+	courier := testProblem(t).Couriers[0]
+	order := Order{OrderId: 1, Weight: 5, Region: 1, DeliveryHours: hours(t, "09:00-12:00"), Cost: 100}
+
+	group, ok := testPlanner().Insert(courier, nil, order)
+	require.True(t, ok)
+	require.Equal(t, 540, group.Start)
+
+	fixed := &Group{GroupOrderId: 3, Orders: []Order{order}, Start: 540, Finish: 600, Fixed: true}
+	_, ok = testPlanner().Insert(courier, []*Group{fixed}, Order{OrderId: 2, Weight: 1, Region: 1, DeliveryHours: hours(t, "09:00-12:00")})
+	require.False(t, ok, "courier is busy for the whole window")
+}
+
+func TestByUrgency(t *testing.T) {
+	promised := func(minute int) *int { return &minute }
+	orders := byUrgency([]Order{
+		{OrderId: 1},
+		{OrderId: 2, PromisedBy: promised(600)},
+		{OrderId: 3, Priority: 1},
+		{OrderId: 4, PromisedBy: promised(540)},
+		{OrderId: 5},
+	})
+
+	ids := make([]int64, len(orders))
+	for i, order := range orders {
+		ids[i] = order.OrderId
+	}
+	require.Equal(t, []int64{3, 4, 2, 1, 5}, ids)
+	require.Equal(t, 540, deadline(orders[1]))
+}
+
+func TestGroupDiscountInScore(t *testing.T) {
+	problem := Problem{
+		Couriers: []Courier{
+			{CourierId: 1, CourierType: model.CourierTypeFoot, Regions: []int64{1}, WorkingHours: hours(t, "09:00-12:00")},
+		},
+		Orders: []Order{
+			{OrderId: 1, Weight: 1, Region: 1, DeliveryHours: hours(t, "09:00-12:00"), Cost: 100},
+			{OrderId: 2, Weight: 1, Region: 1, DeliveryHours: hours(t, "09:00-12:00"), Cost: 100},
+		},
+		Pricing: pricing.Rules{model.CourierTypeFoot: {NextOrderDiscount: 20}},
+	}
+
+	result := NewGreedyByCost(testPlanner()).Assign(problem)
+	require.Len(t, result.Plans[0].Groups, 1)
+	require.Equal(t, int64(180), Evaluate(result).DeliveredCost)
+}
@@ -0,0 +1,84 @@
+package assignment
+
+import (
+	"Ya.SumSchool23/services/model"
+	"sort"
+)
+
+// Reasons an order is left unassigned, from the closest miss to the farthest.
+const (
+	ReasonCapacityExhausted   = "capacity_exhausted"
+	ReasonNoTimeWindowOverlap = "no_time_window_overlap"
+	ReasonOverWeight          = "over_weight"
+	ReasonNoCourierForRegion  = "no_courier_for_region"
+)
+
+const maxNearestMisses = 3
+
+var reasonRanks = map[string]int{
+	ReasonCapacityExhausted:   0,
+	ReasonNoTimeWindowOverlap: 1,
+	ReasonOverWeight:          2,
+	ReasonNoCourierForRegion:  3,
+}
+
+// Rejection explains why an order was left unassigned. Reason is the reason of the courier
+// that came closest to taking the order.
+type Rejection struct {
+	OrderId int64
+	Reason  string
+	// NearestMisses are couriers working in the order region, closest miss first.
+	NearestMisses []Miss
+}
+
+// Miss is the reason a courier could not take an order.
+type Miss struct {
+	CourierId int64
+	Reason    string
+}
+
+// explain finds why none of the couriers could take the order in the final plans.
+func (s *state) explain(order Order) Rejection {
+	misses := make([]Miss, len(s.plans))
+	for i, plan := range s.plans {
+		misses[i] = Miss{CourierId: plan.Courier.CourierId, Reason: MissReason(plan.Courier, order)}
+	}
+	sort.SliceStable(misses, func(a, b int) bool {
+		return reasonRanks[misses[a].Reason] < reasonRanks[misses[b].Reason]
+	})
+
+	rejection := Rejection{OrderId: order.OrderId, Reason: ReasonNoCourierForRegion}
+	if len(misses) > 0 {
+		rejection.Reason = misses[0].Reason
+	}
+	for _, miss := range misses {
+		if miss.Reason == ReasonNoCourierForRegion || len(rejection.NearestMisses) == maxNearestMisses {
+			break
+		}
+		rejection.NearestMisses = append(rejection.NearestMisses, miss)
+	}
+	return rejection
+}
+
+// MissReason returns why the courier cannot take the order regardless of its other groups,
+// or ReasonCapacityExhausted if only the groups already planned are in the way.
+func MissReason(courier Courier, order Order) string {
+	covered := false
+	for _, region := range courier.Regions {
+		covered = covered || region == order.Region
+	}
+	if !covered {
+		return ReasonNoCourierForRegion
+	}
+	if order.Weight > model.CourierTypeProfiles[courier.CourierType].MaxWeight {
+		return ReasonOverWeight
+	}
+	for _, working := range courier.WorkingHours {
+		for _, window := range order.DeliveryHours {
+			if working.Overlaps(window) {
+				return ReasonCapacityExhausted
+			}
+		}
+	}
+	return ReasonNoTimeWindowOverlap
+}
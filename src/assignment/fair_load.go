@@ -0,0 +1,32 @@
+package assignment
+
+// FairLoad assigns the most expensive orders first, each to the courier with the fewest busy minutes so far.
+type FairLoad struct {
+	planner Planner
+}
+
+func NewFairLoad(planner Planner) *FairLoad {
+	return &FairLoad{
+		planner: planner,
+	}
+}
+
+func (a *FairLoad) Name() string {
+	return StrategyFairLoad
+}
+
+func (a *FairLoad) Assign(problem Problem) *Result {
+	return greedy(a.planner, problem, byCostDesc(problem.Orders), leastLoadedPlacement)
+}
+
+func leastLoadedPlacement(_ *state, candidates []placement) placement {
+	best := candidates[0]
+	bestLoad := busyMinutes(best.plan)
+	for _, candidate := range candidates[1:] {
+		load := busyMinutes(candidate.plan)
+		if load < bestLoad || (load == bestLoad && candidate.addedMinutes < best.addedMinutes) {
+			best, bestLoad = candidate, load
+		}
+	}
+	return best
+}
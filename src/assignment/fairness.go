@@ -0,0 +1,131 @@
+package assignment
+
+import (
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/services/model"
+	"fmt"
+	"sort"
+)
+
+// Fairness objectives balance the load of couriers of the same type within the assignment date.
+const (
+	FairnessNone     = ""
+	FairnessEarnings = "earnings"
+	FairnessHours    = "hours"
+)
+
+func ValidateFairness(fairness string) error {
+	switch fairness {
+	case FairnessNone, FairnessEarnings, FairnessHours:
+		return nil
+	}
+	return fmt.Errorf("unknown fairness objective '%s', expected '%s' or '%s'", fairness, FairnessEarnings, FairnessHours)
+}
+
+// TypeFairness measures how evenly the plan spreads earnings and working minutes between couriers of a type.
+// Gini coefficients are 0 for a perfectly even plan and approach 1 when one courier gets everything.
+type TypeFairness struct {
+	CourierType  string
+	Couriers     int
+	EarningsGini float64
+	HoursGini    float64
+}
+
+// fairPlacement prefers the placement whose courier has the lowest load compared to the mean load
+// of couriers of the same type, the load being expected earnings or busy minutes.
+// Placements of equally loaded couriers are left to choose.
+func fairPlacement(fairness string, choose func(s *state, candidates []placement) placement) func(s *state, candidates []placement) placement {
+	return func(s *state, candidates []placement) placement {
+		totals := make(map[string]float64)
+		counts := make(map[string]int)
+		for _, plan := range s.plans {
+			totals[plan.Courier.CourierType] += load(fairness, plan, s.pricing)
+			counts[plan.Courier.CourierType]++
+		}
+
+		var best []placement
+		bestLoad := 0.0
+		for _, candidate := range candidates {
+			courierType := candidate.plan.Courier.CourierType
+			relative := load(fairness, candidate.plan, s.pricing) - totals[courierType]/float64(counts[courierType])
+			if len(best) == 0 || relative < bestLoad {
+				best, bestLoad = []placement{candidate}, relative
+			} else if relative == bestLoad {
+				best = append(best, candidate)
+			}
+		}
+		return choose(s, best)
+	}
+}
+
+// load of the courier plan, historical earnings of the courier are part of its earnings load.
+func load(fairness string, plan *CourierPlan, rules pricing.Rules) float64 {
+	if fairness == FairnessHours {
+		return float64(busyMinutes(plan))
+	}
+	return float64(plan.Courier.Baseline + plannedEarnings(plan, rules))
+}
+
+func plannedEarnings(plan *CourierPlan, rules pricing.Rules) int64 {
+	var cost int64
+	for _, group := range plan.Groups {
+		cost += groupCost(plan.Courier.CourierType, group, rules)
+	}
+	return cost * model.CourierTypeProfiles[plan.Courier.CourierType].EarningsCoefficient
+}
+
+// groupCost is the effective cost of the group orders in delivery order.
+func groupCost(courierType string, group *Group, rules pricing.Rules) int64 {
+	costs := make([]int64, len(group.Orders))
+	for i, order := range group.Orders {
+		costs[i] = order.Cost
+	}
+	return rules.GroupCost(courierType, costs)
+}
+
+// evaluateFairness returns the fairness of the planned earnings and minutes of the date per courier type.
+func evaluateFairness(plans []*CourierPlan, rules pricing.Rules) []TypeFairness {
+	earnings := make(map[string][]float64)
+	minutes := make(map[string][]float64)
+	for _, plan := range plans {
+		courierType := plan.Courier.CourierType
+		earnings[courierType] = append(earnings[courierType], float64(plannedEarnings(plan, rules)))
+		minutes[courierType] = append(minutes[courierType], float64(busyMinutes(plan)))
+	}
+
+	result := make([]TypeFairness, 0, len(earnings))
+	for courierType := range earnings {
+		result = append(result, TypeFairness{
+			CourierType:  courierType,
+			Couriers:     len(earnings[courierType]),
+			EarningsGini: gini(earnings[courierType]),
+			HoursGini:    gini(minutes[courierType]),
+		})
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].CourierType < result[b].CourierType
+	})
+	return result
+}
+
+func gini(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	if len(values) < 2 || total == 0 {
+		return 0
+	}
+
+	diff := 0.0
+	for _, a := range values {
+		for _, b := range values {
+			if a > b {
+				diff += a - b
+			} else {
+				diff += b - a
+			}
+		}
+	}
+	return diff / (2 * float64(len(values)) * total)
+}
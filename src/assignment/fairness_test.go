@@ -0,0 +1,44 @@
+package assignment
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGini(t *testing.T) {
+	require.Equal(t, 0.0, gini([]float64{5, 5, 5}))
+	require.Equal(t, 0.0, gini([]float64{0, 0}))
+	require.Equal(t, 0.0, gini([]float64{7}))
+	require.InDelta(t, 0.5, gini([]float64{0, 10}), 1e-9)
+	require.InDelta(t, 0.25, gini([]float64{1, 3}), 1e-9)
+}
+
+func TestEarningsFairnessPrefersLessPaidCourier(t *testing.T) {
+	problem := Problem{
+		Couriers: []Courier{
+			{CourierId: 1, CourierType: model.CourierTypeFoot, Regions: []int64{1}, WorkingHours: hours(t, "09:00-12:00"), Baseline: 1000},
+			{CourierId: 2, CourierType: model.CourierTypeFoot, Regions: []int64{1}, WorkingHours: hours(t, "09:00-12:00")},
+		},
+		Orders: []Order{
+			{OrderId: 1, Weight: 1, Region: 1, DeliveryHours: hours(t, "09:00-12:00"), Cost: 100},
+		},
+	}
+
+	result := NewGreedyByCost(testPlanner()).Assign(problem)
+	require.Empty(t, result.Plans[1].Groups, "without fairness the smallest courier id wins a tie")
+
+	problem.Fairness = FairnessEarnings
+	result = NewGreedyByCost(testPlanner()).Assign(problem)
+	require.Empty(t, result.Plans[0].Groups)
+	require.Len(t, result.Plans[1].Groups, 1)
+
+	score := Evaluate(result)
+	require.Equal(t, []TypeFairness{{CourierType: model.CourierTypeFoot, Couriers: 2, EarningsGini: 0.5, HoursGini: 0.5}}, score.Fairness)
+}
+
+func TestValidateFairness(t *testing.T) {
+	require.NoError(t, ValidateFairness(FairnessNone))
+	require.NoError(t, ValidateFairness(FairnessHours))
+	require.Error(t, ValidateFairness("luck"))
+}
@@ -0,0 +1,79 @@
+package assignment
+
+// greedy adds orders one by one in the given order, every order goes to the placement picked by choose.
+// With a fairness objective choose only picks among the placements of the least loaded couriers.
+func greedy(planner Planner, problem Problem, orders []Order, choose func(s *state, candidates []placement) placement) *Result {
+	if problem.Fairness != FairnessNone {
+		choose = fairPlacement(problem.Fairness, choose)
+	}
+	s := planner.newState(problem)
+	var unassigned []Order
+	for _, order := range byUrgency(orders) {
+		candidates := s.placements(order)
+		if len(candidates) == 0 {
+			unassigned = append(unassigned, order)
+			continue
+		}
+		s.apply(choose(s, candidates))
+	}
+	return s.result(unassigned)
+}
+
+// cheapestPlacement picks the placement adding the fewest working minutes,
+// candidates come sorted by courier id so ties go to the smallest one.
+func cheapestPlacement(_ *state, candidates []placement) placement {
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.addedMinutes < best.addedMinutes {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// GreedyByCost assigns the most expensive orders first, each to the placement adding the fewest minutes.
+type GreedyByCost struct {
+	planner Planner
+}
+
+func NewGreedyByCost(planner Planner) *GreedyByCost {
+	return &GreedyByCost{
+		planner: planner,
+	}
+}
+
+func (a *GreedyByCost) Name() string {
+	return StrategyGreedyCost
+}
+
+func (a *GreedyByCost) Assign(problem Problem) *Result {
+	return greedy(a.planner, problem, byCostDesc(problem.Orders), cheapestPlacement)
+}
+
+// GreedyByDeadline assigns orders with the earliest end of delivery hours first.
+type GreedyByDeadline struct {
+	planner Planner
+}
+
+func NewGreedyByDeadline(planner Planner) *GreedyByDeadline {
+	return &GreedyByDeadline{
+		planner: planner,
+	}
+}
+
+func (a *GreedyByDeadline) Name() string {
+	return StrategyGreedyDeadline
+}
+
+func (a *GreedyByDeadline) Assign(problem Problem) *Result {
+	orders := sortedOrders(problem.Orders, func(a, b Order) bool {
+		return deadline(a) < deadline(b)
+	})
+	return greedy(a.planner, problem, orders, cheapestPlacement)
+}
+
+func byCostDesc(orders []Order) []Order {
+	return sortedOrders(orders, func(a, b Order) bool {
+		return a.Cost > b.Cost
+	})
+}
@@ -0,0 +1,46 @@
+package assignment
+
+const maxLocalSearchEvaluations = 300
+
+// LocalSearch starts from the greedy by cost insertion order and swaps pairs of orders
+// in it while the resulting plan scores better, up to a fixed number of evaluated plans.
+type LocalSearch struct {
+	planner Planner
+}
+
+func NewLocalSearch(planner Planner) *LocalSearch {
+	return &LocalSearch{
+		planner: planner,
+	}
+}
+
+func (a *LocalSearch) Name() string {
+	return StrategyLocalSearch
+}
+
+func (a *LocalSearch) Assign(problem Problem) *Result {
+	orders := byCostDesc(problem.Orders)
+	best := greedy(a.planner, problem, orders, cheapestPlacement)
+	bestScore := Evaluate(best)
+
+	evaluations := 1
+	for improved := true; improved && evaluations < maxLocalSearchEvaluations; {
+		improved = false
+		for i := 0; i < len(orders)-1 && !improved && evaluations < maxLocalSearchEvaluations; i++ {
+			for j := i + 1; j < len(orders) && evaluations < maxLocalSearchEvaluations; j++ {
+				candidate := make([]Order, len(orders))
+				copy(candidate, orders)
+				candidate[i], candidate[j] = candidate[j], candidate[i]
+
+				result := greedy(a.planner, problem, candidate, cheapestPlacement)
+				score := Evaluate(result)
+				evaluations++
+				if score.Better(bestScore) {
+					orders, best, bestScore, improved = candidate, result, score, true
+					break
+				}
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,251 @@
+package assignment
+
+import (
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/routing"
+	"Ya.SumSchool23/services/model"
+	"sort"
+)
+
+// Planner builds courier timelines: it finds where an order can be added and keeps groups feasible.
+type Planner struct {
+	sequencer *routing.Sequencer
+}
+
+func NewPlanner(sequencer *routing.Sequencer) Planner {
+	return Planner{
+		sequencer: sequencer,
+	}
+}
+
+// placement is a feasible way to add an order: a new version of an existing group or a new group.
+type placement struct {
+	plan         *CourierPlan
+	groupIndex   int
+	group        *Group
+	addedMinutes int
+}
+
+type state struct {
+	planner Planner
+	plans   []*CourierPlan
+	pricing pricing.Rules
+}
+
+func (p Planner) newState(problem Problem) *state {
+	s := &state{planner: p, plans: make([]*CourierPlan, len(problem.Couriers)), pricing: problem.Pricing}
+	for i, courier := range problem.Couriers {
+		plan := &CourierPlan{Courier: courier}
+		for _, group := range problem.Fixed[courier.CourierId] {
+			fixed := *group
+			fixed.Fixed = true
+			plan.Groups = append(plan.Groups, &fixed)
+		}
+		for _, group := range problem.Pinned[courier.CourierId] {
+			pinned := *group
+			pinned.Fixed = false
+			plan.Groups = append(plan.Groups, &pinned)
+		}
+		sortGroups(plan.Groups)
+		s.plans[i] = plan
+	}
+	sort.Slice(s.plans, func(a, b int) bool {
+		return s.plans[a].Courier.CourierId < s.plans[b].Courier.CourierId
+	})
+	return s
+}
+
+// Insert finds the cheapest feasible way to add the order to the courier's groups: an extended
+// group that is not fixed (with its GroupOrderId) or a new group.
+func (p Planner) Insert(courier Courier, groups []*Group, order Order) (*Group, bool) {
+	problem := Problem{
+		Couriers: []Courier{courier},
+		Fixed:    make(map[int64][]*Group),
+		Pinned:   make(map[int64][]*Group),
+	}
+	for _, group := range groups {
+		if group.Fixed {
+			problem.Fixed[courier.CourierId] = append(problem.Fixed[courier.CourierId], group)
+		} else {
+			problem.Pinned[courier.CourierId] = append(problem.Pinned[courier.CourierId], group)
+		}
+	}
+
+	s := p.newState(problem)
+	candidates := s.placements(order)
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	return cheapestPlacement(s, candidates).group, true
+}
+
+// placements returns every feasible way to add the order to the courier plans.
+func (s *state) placements(order Order) []placement {
+	var result []placement
+	for _, plan := range s.plans {
+		if !canCarry(plan.Courier, order) {
+			continue
+		}
+		for i, group := range plan.Groups {
+			if group.Fixed {
+				continue
+			}
+			if extended, ok := s.extendGroup(plan, i, order); ok {
+				result = append(result, placement{
+					plan:         plan,
+					groupIndex:   i,
+					group:        extended,
+					addedMinutes: extended.Finish - group.Finish,
+				})
+			}
+		}
+		if group, ok := s.newGroup(plan, order); ok {
+			result = append(result, placement{
+				plan:         plan,
+				groupIndex:   -1,
+				group:        group,
+				addedMinutes: group.Finish - group.Start,
+			})
+		}
+	}
+	return result
+}
+
+func (s *state) apply(p placement) {
+	if p.groupIndex >= 0 {
+		p.plan.Groups[p.groupIndex] = p.group
+		return
+	}
+	p.plan.Groups = append(p.plan.Groups, p.group)
+	sortGroups(p.plan.Groups)
+}
+
+func (s *state) result(unassigned []Order) *Result {
+	result := &Result{Plans: s.plans, Unassigned: unassigned, pricing: s.pricing}
+	for _, order := range unassigned {
+		result.Rejections = append(result.Rejections, s.explain(order))
+	}
+	return result
+}
+
+func (s *state) extendGroup(plan *CourierPlan, index int, order Order) (*Group, bool) {
+	group := plan.Groups[index]
+	profile := model.CourierTypeProfiles[plan.Courier.CourierType]
+	if len(group.Orders)+1 > profile.MaxOrders {
+		return nil, false
+	}
+
+	weight := order.Weight
+	regions := map[int64]bool{order.Region: true}
+	for _, o := range group.Orders {
+		weight += o.Weight
+		regions[o.Region] = true
+	}
+	if weight > profile.MaxWeight || len(regions) > profile.MaxRegions {
+		return nil, false
+	}
+
+	limit, ok := s.limit(plan, group.Start, index)
+	if !ok {
+		return nil, false
+	}
+	orders := append(append([]Order{}, group.Orders...), order)
+	extended, ok := s.planner.Sequence(plan.Courier.CourierType, group.Start, orders)
+	if !ok || extended.Finish > limit {
+		return nil, false
+	}
+	extended.GroupOrderId = group.GroupOrderId
+	return extended, true
+}
+
+// newGroup plans a group with the single order right after the last group of one of the working intervals.
+func (s *state) newGroup(plan *CourierPlan, order Order) (*Group, bool) {
+	for _, window := range plan.Courier.WorkingHours {
+		start := window.Start
+		for _, group := range plan.Groups {
+			if group.Start >= window.Start && group.Start < window.End && group.Finish > start {
+				start = group.Finish
+			}
+		}
+		if start >= window.End {
+			continue
+		}
+		group, ok := s.planner.Sequence(plan.Courier.CourierType, start, []Order{order})
+		if ok && group.Finish <= window.End {
+			return group, true
+		}
+	}
+	return nil, false
+}
+
+// limit returns the latest finish of the group at the index: the start of the next group
+// in the same working interval or the end of the interval.
+func (s *state) limit(plan *CourierPlan, start int, index int) (int, bool) {
+	for _, window := range plan.Courier.WorkingHours {
+		if start < window.Start || start >= window.End {
+			continue
+		}
+		limit := window.End
+		for i, group := range plan.Groups {
+			if i != index && group.Start >= start && group.Start < limit {
+				limit = group.Start
+			}
+		}
+		return limit, true
+	}
+	return 0, false
+}
+
+// Sequence plans the delivery order of the orders for a group starting at the minute,
+// it returns false if some order cannot be delivered inside its delivery hours.
+func (p Planner) Sequence(courierType string, start int, orders []Order) (*Group, bool) {
+	stops := make([]routing.Stop, len(orders))
+	byId := make(map[int64]Order, len(orders))
+	for i, order := range orders {
+		stops[i] = routing.Stop{OrderId: order.OrderId, Region: order.Region, Windows: order.DeliveryHours}
+		byId[order.OrderId] = order
+	}
+
+	plan, ok := p.sequencer.Sequence(courierType, start, stops)
+	if !ok || !plan.Feasible {
+		return nil, false
+	}
+
+	group := &Group{
+		Orders:   make([]Order, len(plan.Stops)),
+		Arrivals: make([]int, len(plan.Stops)),
+		Start:    start,
+		Finish:   plan.Finish,
+	}
+	for i, stop := range plan.Stops {
+		group.Orders[i] = byId[stop.OrderId]
+		group.Arrivals[i] = stop.Arrival
+	}
+	return group, true
+}
+
+func canCarry(courier Courier, order Order) bool {
+	if order.Weight > model.CourierTypeProfiles[courier.CourierType].MaxWeight {
+		return false
+	}
+	for _, region := range courier.Regions {
+		if region == order.Region {
+			return true
+		}
+	}
+	return false
+}
+
+func busyMinutes(plan *CourierPlan) int {
+	total := 0
+	for _, group := range plan.Groups {
+		total += group.Finish - group.Start
+	}
+	return total
+}
+
+func sortGroups(groups []*Group) {
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Start < groups[b].Start
+	})
+}
@@ -0,0 +1,44 @@
+package assignment
+
+import "Ya.SumSchool23/time_interval"
+
+// Score measures the quality of an assignment so strategies can be compared on the same problem.
+type Score struct {
+	// DeliveredCost is the total effective cost of assigned orders with group discounts, fixed groups included.
+	DeliveredCost int64
+	Assigned      int
+	Unassigned    int
+	// Utilization is the share of the couriers' working minutes spent on group orders.
+	Utilization float64
+	Fairness    []TypeFairness
+}
+
+func Evaluate(result *Result) Score {
+	score := Score{Unassigned: len(result.Unassigned)}
+
+	busy, available := 0, 0
+	for _, plan := range result.Plans {
+		for _, group := range plan.Groups {
+			score.DeliveredCost += groupCost(plan.Courier.CourierType, group, result.pricing)
+			score.Assigned += len(group.Orders)
+		}
+		busy += busyMinutes(plan)
+		available += time_interval.TotalMinutes(plan.Courier.WorkingHours)
+	}
+	if available > 0 {
+		score.Utilization = float64(busy) / float64(available)
+	}
+	score.Fairness = evaluateFairness(result.Plans, result.pricing)
+	return score
+}
+
+// Better prefers more delivered cost, then fewer unassigned orders, then fewer busy minutes.
+func (s Score) Better(other Score) bool {
+	if s.DeliveredCost != other.DeliveredCost {
+		return s.DeliveredCost > other.DeliveredCost
+	}
+	if s.Unassigned != other.Unassigned {
+		return s.Unassigned < other.Unassigned
+	}
+	return s.Utilization < other.Utilization
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	postOrdersAssign = handlerName(iota)
+	getCouriersAssignments
+	getAssignmentExplanation
+	postOrderAssignTo
+)
+
+const (
+	assignModeFull        = "full"
+	assignModeIncremental = "incremental"
+)
+
+type AssignmentController struct {
+	assignmentService *services.AssignmentService
+	adminToken        string
+	rateLimiters      map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewAssignmentController(s *services.AssignmentService, adminToken string) *AssignmentController {
+	return &AssignmentController{
+		assignmentService: s,
+		adminToken:        adminToken,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			postOrdersAssign:         rate_limiter.NewRateLimiter(),
+			getCouriersAssignments:   rate_limiter.NewRateLimiter(),
+			getAssignmentExplanation: rate_limiter.NewRateLimiter(),
+			postOrderAssignTo:        rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+// PostOrdersAssign distributes open orders between couriers for the date (today by default)
+// with the strategy from the 'strategy' query param or the configured one.
+// 'fairness' chooses the fairness objective: earnings, hours or none.
+// With 'mode=incremental' existing group orders are kept and only new orders are added to them or to new groups.
+// With 'dry_run=true' the plan is only previewed, the body may then add hypothetical couriers and orders.
+func (c *AssignmentController) PostOrdersAssign(ctx echo.Context) error {
+	if !c.rateLimiters[postOrdersAssign].RegisterCall() {
+		return cerrors.TooManyRequests.New("post orders assign method overloaded")
+	}
+
+	date, err := parseOptionalDateQueryParam(ctx, "date")
+	if err != nil {
+		return err
+	}
+
+	dryRun := false
+	if dryRunStr := ctx.QueryParam("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'dry_run', got '%s'", dryRunStr)
+		}
+	}
+
+	incremental := false
+	switch mode := ctx.QueryParam("mode"); mode {
+	case "", assignModeFull:
+	case assignModeIncremental:
+		incremental = true
+	default:
+		return cerrors.BadRequest.Newf("unknown assignment mode '%s', expected '%s' or '%s'",
+			mode, assignModeFull, assignModeIncremental)
+	}
+
+	data := service_data.NewAssignData{
+		Date:        date,
+		Strategy:    ctx.QueryParam("strategy"),
+		Incremental: incremental,
+		Fairness:    ctx.QueryParam("fairness"),
+		Actor:       actorOf(ctx),
+	}
+
+	request := new(dto.AssignWhatIfRequest)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse assign what-if request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid assign what-if request")
+	}
+	hypothetical := len(request.Couriers) > 0 || len(request.Orders) > 0
+	if hypothetical && !dryRun {
+		return cerrors.BadRequest.New("hypothetical couriers and orders are allowed only with 'dry_run=true'")
+	}
+
+	if !dryRun {
+		result, err := c.assignmentService.Assign(ctx.Request().Context(), data)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(http.StatusCreated, []dto.OrderAssignResponse{toOrderAssignResponse(result)})
+	}
+
+	whatIf := &service_data.NewWhatIfData{
+		Couriers: make([]service_data.NewCourierData, len(request.Couriers)),
+		Orders:   make([]service_data.NewOrderData, len(request.Orders)),
+	}
+	for i, courier := range request.Couriers {
+		whatIf.Couriers[i].CourierType = courier.CourierType
+		whatIf.Couriers[i].Regions = courier.Regions
+		whatIf.Couriers[i].WorkingHours = courier.WorkingHours
+	}
+	for i, order := range request.Orders {
+		whatIf.Orders[i].Weight = order.Weight
+		whatIf.Orders[i].Regions = order.Regions
+		whatIf.Orders[i].DeliveryHours = order.DeliveryHours
+		cost := order.Cost
+		whatIf.Orders[i].Cost = &cost
+	}
+
+	result, err := c.assignmentService.PreviewAssign(data, whatIf)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, []dto.OrderAssignResponse{toOrderAssignResponse(result)})
+}
+
+func (c *AssignmentController) GetCouriersAssignments(ctx echo.Context) error {
+	if !c.rateLimiters[getCouriersAssignments].RegisterCall() {
+		return cerrors.TooManyRequests.New("get couriers assignments method overloaded")
+	}
+
+	date, err := parseOptionalDateQueryParam(ctx, "date")
+	if err != nil {
+		return err
+	}
+
+	var courierId *int64
+	if ctx.QueryParam("courier_id") != "" {
+		id, err := parseInt64QueryParam(ctx, "courier_id", 0)
+		if err != nil {
+			return err
+		}
+		courierId = &id
+	}
+
+	result, err := c.assignmentService.GetAssignments(date, courierId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toOrderAssignResponse(result))
+}
+
+// GetAssignmentExplanation returns why the last assignment run left the order unassigned
+// and the couriers that came closest to taking it.
+func (c *AssignmentController) GetAssignmentExplanation(ctx echo.Context) error {
+	if !c.rateLimiters[getAssignmentExplanation].RegisterCall() {
+		return cerrors.TooManyRequests.New("get assignment explanation method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "order_id")
+	if err != nil {
+		return err
+	}
+
+	order, rejection, err := c.assignmentService.GetAssignmentExplanation(id)
+	if err != nil {
+		return err
+	}
+
+	response := dto.AssignmentExplanationDto{
+		OrderId:      order.OrderId,
+		Assigned:     order.GroupOrderId != nil,
+		GroupOrderId: order.GroupOrderId,
+	}
+	if rejection != nil {
+		response.Date = rejection.Date.Format(dateLayout)
+		response.Strategy = rejection.Strategy
+		response.Reason = rejection.Reason
+		response.NearestMisses = make([]dto.NearestMissDto, len(rejection.NearestMisses))
+		for i, miss := range rejection.NearestMisses {
+			response.NearestMisses[i] = dto.NearestMissDto{CourierId: miss.CourierId, Reason: miss.Reason}
+		}
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// PostOrderAssignTo assigns the order to the courier for the date (today by default) and pins it there.
+// With 'force=true' capacity and working hours are not checked, only admins may force an assignment.
+func (c *AssignmentController) PostOrderAssignTo(ctx echo.Context) error {
+	if !c.rateLimiters[postOrderAssignTo].RegisterCall() {
+		return cerrors.TooManyRequests.New("post order assign to method overloaded")
+	}
+
+	orderId, err := parseInt64PathParam(ctx, "order_id")
+	if err != nil {
+		return err
+	}
+	courierId, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+	date, err := parseOptionalDateQueryParam(ctx, "date")
+	if err != nil {
+		return err
+	}
+
+	force := false
+	if forceStr := ctx.QueryParam("force"); forceStr != "" {
+		force, err = strconv.ParseBool(forceStr)
+		if err != nil {
+			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'force', got '%s'", forceStr)
+		}
+	}
+	if force && !isAdmin(ctx, c.adminToken) {
+		return cerrors.Forbidden.New("only admins may force an assignment")
+	}
+
+	override, err := c.assignmentService.AssignToCourier(ctx.Request().Context(), orderId, courierId, date, force)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.AssignmentOverrideDto{
+		OverrideId:        override.OverrideId,
+		OrderId:           override.OrderId,
+		CourierId:         override.CourierId,
+		PreviousCourierId: override.PreviousCourierId,
+		GroupOrderId:      override.GroupOrderId,
+		Date:              override.Date.Format(dateLayout),
+		Forced:            override.Forced,
+		CreatedAt:         override.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// parseOptionalDateQueryParam returns the current UTC date if the param is not set.
+func parseOptionalDateQueryParam(ctx echo.Context, name string) (time.Time, error) {
+	if ctx.QueryParam(name) == "" {
+		return time.Parse(dateLayout, time.Now().UTC().Format(dateLayout))
+	}
+	return parseDateQueryParam(ctx, name)
+}
+
+func toOrderAssignResponse(data *service_data.NewOrderAssignResponseData) dto.OrderAssignResponse {
+	response := dto.OrderAssignResponse{
+		Date:     data.Date,
+		Couriers: make([]dto.CouriersGroupOrdersDto, len(data.Couriers)),
+		Strategy: data.Strategy,
+	}
+	for i, courier := range data.Couriers {
+		response.Couriers[i].CourierId = courier.CourierId
+		response.Couriers[i].Orders = make([]dto.GroupOrdersDto, len(courier.Orders))
+		for j, group := range courier.Orders {
+			groupDto := dto.GroupOrdersDto{
+				GroupOrderId: group.GroupOrderId,
+				Orders:       make([]dto.OrderDto, len(group.Orders)),
+			}
+			for k, order := range group.Orders {
+				groupDto.Orders[k] = dto.OrderDto{
+					OrderId:        order.OrderId,
+					Weight:         order.Weight,
+					Regions:        order.Regions,
+					DeliveryHours:  order.DeliveryHours,
+					Cost:           order.Cost,
+					CompletedTime:  order.CompletedTime,
+					PlannedArrival: order.PlannedArrival,
+				}
+			}
+			response.Couriers[i].Orders[j] = groupDto
+		}
+	}
+	if data.Metrics != nil {
+		response.Metrics = &dto.AssignmentMetricsDto{
+			DeliveredCost: data.Metrics.DeliveredCost,
+			Assigned:      data.Metrics.Assigned,
+			Unassigned:    data.Metrics.Unassigned,
+			Utilization:   data.Metrics.Utilization,
+			Fairness:      make([]dto.TypeFairnessDto, len(data.Metrics.Fairness)),
+		}
+		for i, fairness := range data.Metrics.Fairness {
+			response.Metrics.Fairness[i] = dto.TypeFairnessDto(fairness)
+		}
+	}
+	return response
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const (
+	getAudit = handlerName(iota)
+)
+
+type AuditController struct {
+	auditService *services.AuditService
+	rateLimiters map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewAuditController(s *services.AuditService) *AuditController {
+	return &AuditController{
+		auditService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getAudit: rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+// GetAudit returns audit entries newest first, filtered by actor, entity and a [from, to) time range.
+// Filtering by entity_id needs the entity_type.
+func (c *AuditController) GetAudit(ctx echo.Context) error {
+	if !c.rateLimiters[getAudit].RegisterCall() {
+		return cerrors.TooManyRequests.New("get audit method overloaded")
+	}
+
+	filter := service_data.AuditFilterData{
+		Actor:      ctx.QueryParam("actor"),
+		EntityType: ctx.QueryParam("entity_type"),
+	}
+	var err error
+	if filter.Limit, err = parseInt64QueryParam(ctx, "limit", 1); err != nil {
+		return err
+	}
+	if filter.Offset, err = parseInt64QueryParam(ctx, "offset", 0); err != nil {
+		return err
+	}
+	if ctx.QueryParam("entity_id") != "" {
+		if filter.EntityType == "" {
+			return cerrors.BadRequest.New("query param 'entity_id' needs 'entity_type'")
+		}
+		entityId, err := parseInt64QueryParam(ctx, "entity_id", 0)
+		if err != nil {
+			return err
+		}
+		filter.EntityId = &entityId
+	}
+	if filter.From, err = parseOptionalTimeQueryParam(ctx, "from"); err != nil {
+		return err
+	}
+	if filter.To, err = parseOptionalTimeQueryParam(ctx, "to"); err != nil {
+		return err
+	}
+
+	entries, err := c.auditService.GetEntries(filter)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.AuditEntryDto, len(entries))
+	for i, entry := range entries {
+		response[i] = toAuditEntryDto(entry)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// parseOptionalTimeQueryParam parses an RFC3339 time, nil if the param is not set.
+func parseOptionalTimeQueryParam(ctx echo.Context, name string) (*time.Time, error) {
+	str := ctx.QueryParam(name)
+	if str == "" {
+		return nil, nil
+	}
+	value, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, cerrors.BadRequest.Wrapf(err, "cannot parse query param '%s', got '%s'", name, str)
+	}
+	return &value, nil
+}
+
+func toAuditEntryDto(entry *model.AuditEntry) dto.AuditEntryDto {
+	return dto.AuditEntryDto{
+		Id:         entry.Id,
+		Actor:      entry.Actor,
+		Method:     entry.Method,
+		Route:      entry.Route,
+		EntityIds:  entry.EntityIds,
+		EntityType: entry.EntityType,
+		EntityId:   entry.EntityId,
+		Before:     entry.Before,
+		After:      entry.After,
+		Diff:       entry.Diff,
+		RequestId:  entry.RequestId,
+		Status:     entry.Status,
+		CreatedAt:  entry.CreatedAt.Format(time.RFC3339),
+	}
+}
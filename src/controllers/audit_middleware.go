@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/services"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// Audit records every mutating call with its actor, route, path params, request id and status.
+// Services add the entities they changed with their state before and after the call.
+// The request id is taken from the X-Request-ID header or generated, and returned in the response.
+func Audit(s *services.AuditService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if !isMutating(ctx.Request().Method) {
+				return next(ctx)
+			}
+
+			requestId := ctx.Request().Header.Get(echo.HeaderXRequestID)
+			if requestId == "" {
+				requestId = newRequestId()
+			}
+			ctx.Response().Header().Set(echo.HeaderXRequestID, requestId)
+
+			scope := services.NewAuditScope(actorOf(ctx), requestId)
+			ctx.SetRequest(ctx.Request().WithContext(services.WithAuditScope(ctx.Request().Context(), scope)))
+
+			// the error is handled here to record the status it is answered with
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			entityIds := make(map[string]string)
+			for i, name := range ctx.ParamNames() {
+				entityIds[name] = ctx.ParamValues()[i]
+			}
+			err := s.RecordRequest(scope, ctx.Request().Method, ctx.Path(), entityIds, ctx.Response().Status)
+			if err != nil {
+				ctx.Logger().Errorf("failed to record audit entry of request '%s': %s", requestId, err.Error())
+			}
+			return nil
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
@@ -17,6 +17,9 @@ const (
 	getCourierById
 	getCourierMetaById
 	postCouriers
+	getAvailableCouriers
+	postCourierDeactivate
+	postCourierActivate
 )
 
 type CourierController struct {
@@ -28,10 +31,13 @@ func NewCourierController(s *services.CourierService) *CourierController {
 	return &CourierController{
 		courierService: s,
 		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
-			getCouriers:        rate_limiter.NewRateLimiter(),
-			getCourierById:     rate_limiter.NewRateLimiter(),
-			getCourierMetaById: rate_limiter.NewRateLimiter(),
-			postCouriers:       rate_limiter.NewRateLimiter(),
+			getCouriers:           rate_limiter.NewRateLimiter(),
+			getCourierById:        rate_limiter.NewRateLimiter(),
+			getCourierMetaById:    rate_limiter.NewRateLimiter(),
+			postCouriers:          rate_limiter.NewRateLimiter(),
+			getAvailableCouriers:  rate_limiter.NewRateLimiter(),
+			postCourierDeactivate: rate_limiter.NewRateLimiter(),
+			postCourierActivate:   rate_limiter.NewRateLimiter(),
 		},
 	}
 }
@@ -41,42 +47,67 @@ func (c *CourierController) GetCouriers(ctx echo.Context) error {
 		return cerrors.TooManyRequests.New("get couriers method overloaded")
 	}
 
-	var limit int64
-	limitStr := ctx.QueryParam("limit")
-	if limitStr == "" {
-		limit = 1
-	} else {
-		l, err := strconv.ParseInt(limitStr, 10, 64)
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	couriers, err := c.courierService.GetCouriers(limit, offset)
+	if err != nil {
+		return err
+	}
+
+	couriersDto := make([]dto.CourierDto, len(couriers))
+	for i := 0; i < len(couriers); i++ {
+		couriersDto[i] = toCourierDto(couriers[i])
+	}
+	return ctx.JSON(http.StatusOK, couriersDto)
+}
+
+func (c *CourierController) GetAvailableCouriers(ctx echo.Context) error {
+	if !c.rateLimiters[getAvailableCouriers].RegisterCall() {
+		return cerrors.TooManyRequests.New("get available couriers method overloaded")
+	}
+
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	var region *int64
+	if ctx.QueryParam("region") != "" {
+		r, err := parseInt64QueryParam(ctx, "region", 0)
 		if err != nil {
-			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'limit', got '%s'", limitStr)
+			return err
 		}
-		limit = l
+		region = &r
 	}
 
-	var offset int64
-	offsetStr := ctx.QueryParam("offset")
-	if offsetStr == "" {
-		offset = 0
-	} else {
-		off, err := strconv.ParseInt(offsetStr, 10, 64)
+	var date *time.Time
+	if ctx.QueryParam("date") != "" {
+		d, err := parseDateQueryParam(ctx, "date")
 		if err != nil {
-			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'offset', got '%s'", offsetStr)
+			return err
 		}
-		offset = off
+		date = &d
 	}
 
-	couriers, err := c.courierService.GetCouriers(limit, offset)
+	couriers, err := c.courierService.GetAvailableCouriers(region, date, limit, offset)
 	if err != nil {
 		return err
 	}
 
 	couriersDto := make([]dto.CourierDto, len(couriers))
-
 	for i := 0; i < len(couriers); i++ {
-		couriersDto[i].CourierId = couriers[i].CourierId
-		couriersDto[i].CourierType = couriers[i].CourierType
-		couriersDto[i].Regions = couriers[i].Regions
-		couriersDto[i].WorkingHours = couriers[i].WorkingHours
+		couriersDto[i] = toCourierDto(couriers[i])
 	}
 	return ctx.JSON(http.StatusOK, couriersDto)
 }
@@ -86,24 +117,16 @@ func (c *CourierController) GetCourierById(ctx echo.Context) error {
 		return cerrors.TooManyRequests.New("get courier by id method overloaded")
 	}
 
-	idStr := ctx.Param("courier_id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := parseInt64PathParam(ctx, "courier_id")
 	if err != nil {
-		return cerrors.BadRequest.Wrapf(err, "cannot parse path param 'courier_id', got '%s'", idStr)
+		return err
 	}
 
 	courier, err := c.courierService.GetCourierById(id)
 	if err != nil {
 		return err
 	}
-
-	courierDto := dto.CourierDto{
-		CourierId:    courier.CourierId,
-		CourierType:  courier.CourierType,
-		Regions:      courier.Regions,
-		WorkingHours: courier.WorkingHours,
-	}
-	return ctx.JSON(http.StatusOK, courierDto)
+	return ctx.JSON(http.StatusOK, toCourierDto(courier))
 }
 
 func (c *CourierController) GetCourierMetaById(ctx echo.Context) error {
@@ -111,29 +134,46 @@ func (c *CourierController) GetCourierMetaById(ctx echo.Context) error {
 		return cerrors.TooManyRequests.New("get courier meta by id method overloaded")
 	}
 
-	idStr := ctx.Param("courier_id")
-	startDateStr := ctx.QueryParam("startDate")
-	endDateStr := ctx.QueryParam("endDate")
-
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := parseInt64PathParam(ctx, "courier_id")
 	if err != nil {
-		return cerrors.BadRequest.Wrapf(err, "cannot parse path param 'courier_id', got '%s'", idStr)
+		return err
 	}
-
-	layout := "2006-01-02"
-	startDate, err := time.Parse(layout, startDateStr)
+	startDate, err := parseDateQueryParam(ctx, "startDate")
+	if err != nil {
+		return err
+	}
+	endDate, err := parseDateQueryParam(ctx, "endDate")
 	if err != nil {
-		return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'startDate', got '%s'", startDateStr)
+		return err
+	}
+	if !endDate.After(startDate) {
+		return cerrors.BadRequest.Newf("'endDate' must be after 'startDate', got '%s' and '%s'",
+			endDate.Format(dateLayout), startDate.Format(dateLayout))
 	}
 
-	endDate, err := time.Parse(layout, endDateStr)
+	metaInfo, err := c.courierService.GetCourierMetaInfo(id, startDate, endDate)
 	if err != nil {
-		return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'endDate', got '%s'", endDateStr)
+		return err
 	}
 
-	return cerrors.NotImplemented.Newf(
-		"Sorry I did not implement and complete this method because of not enough time. Params: %s, %s, %s",
-		id, startDate, endDate)
+	response := dto.GetCourierMetaInfoResponse{
+		CourierId:    metaInfo.Courier.CourierId,
+		CourierType:  metaInfo.Courier.CourierType,
+		Regions:      metaInfo.Courier.Regions,
+		WorkingHours: metaInfo.Courier.WorkingHours,
+	}
+	if metaInfo.Rating != nil {
+		rating := int32(*metaInfo.Rating)
+		response.Rating = &rating
+	}
+	if metaInfo.Earnings != nil {
+		earnings := int32(*metaInfo.Earnings)
+		response.Earnings = &earnings
+	}
+	if metaInfo.Quality != nil {
+		response.Quality = &metaInfo.Quality.Score
+	}
+	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *CourierController) PostCouriers(ctx echo.Context) error {
@@ -156,7 +196,7 @@ func (c *CourierController) PostCouriers(ctx echo.Context) error {
 		data[i].WorkingHours = createCourierRequest.Couriers[i].WorkingHours
 	}
 
-	createdCouriers, err := c.courierService.CreateCouriers(data)
+	createdCouriers, err := c.courierService.CreateCouriers(ctx.Request().Context(), data)
 	if err != nil {
 		return err
 	}
@@ -164,10 +204,49 @@ func (c *CourierController) PostCouriers(ctx echo.Context) error {
 	response.Couriers = make([]dto.CourierDto, len(createdCouriers))
 
 	for i := 0; i < len(createdCouriers); i++ {
-		response.Couriers[i].CourierId = createdCouriers[i].CourierId
-		response.Couriers[i].CourierType = createdCouriers[i].CourierType
-		response.Couriers[i].Regions = createdCouriers[i].Regions
-		response.Couriers[i].WorkingHours = createdCouriers[i].WorkingHours
+		response.Couriers[i] = toCourierDto(createdCouriers[i])
 	}
 	return ctx.JSON(http.StatusOK, response)
 }
+
+func (c *CourierController) PostCourierDeactivate(ctx echo.Context) error {
+	if !c.rateLimiters[postCourierDeactivate].RegisterCall() {
+		return cerrors.TooManyRequests.New("post courier deactivate method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	archive := false
+	if archiveStr := ctx.QueryParam("archive"); archiveStr != "" {
+		archive, err = strconv.ParseBool(archiveStr)
+		if err != nil {
+			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'archive', got '%s'", archiveStr)
+		}
+	}
+
+	courier, err := c.courierService.DeactivateCourier(ctx.Request().Context(), id, archive)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toCourierDto(courier))
+}
+
+func (c *CourierController) PostCourierActivate(ctx echo.Context) error {
+	if !c.rateLimiters[postCourierActivate].RegisterCall() {
+		return cerrors.TooManyRequests.New("post courier activate method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	courier, err := c.courierService.ActivateCourier(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toCourierDto(courier))
+}
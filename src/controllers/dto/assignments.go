@@ -0,0 +1,81 @@
+package dto
+
+type OrderAssignResponse struct {
+	Date     string                   `json:"date"`
+	Couriers []CouriersGroupOrdersDto `json:"couriers"`
+	Strategy string                   `json:"strategy,omitempty"`
+	Metrics  *AssignmentMetricsDto    `json:"metrics,omitempty"`
+}
+
+type CouriersGroupOrdersDto struct {
+	CourierId int64            `json:"courier_id"`
+	Orders    []GroupOrdersDto `json:"orders"`
+}
+
+type GroupOrdersDto struct {
+	GroupOrderId int64      `json:"group_order_id"`
+	Orders       []OrderDto `json:"orders"`
+}
+
+// AssignmentMetricsDto is the quality of an assignment run, utilization is a share from 0 to 1.
+type AssignmentMetricsDto struct {
+	DeliveredCost int64   `json:"delivered_cost"`
+	Assigned      int     `json:"assigned"`
+	Unassigned    int     `json:"unassigned"`
+	Utilization   float64 `json:"utilization"`
+	// Fairness is reported per courier type, Gini coefficients are from 0 (even) to 1
+	Fairness []TypeFairnessDto `json:"fairness"`
+}
+
+type TypeFairnessDto struct {
+	CourierType  string  `json:"courier_type"`
+	Couriers     int     `json:"couriers"`
+	EarningsGini float64 `json:"earnings_gini"`
+	HoursGini    float64 `json:"hours_gini"`
+}
+
+// AssignWhatIfRequest adds hypothetical couriers and orders to a dry run of the assignment.
+type AssignWhatIfRequest struct {
+	Couriers []WhatIfCourierDto `json:"couriers" validate:"dive"`
+	Orders   []WhatIfOrderDto   `json:"orders" validate:"dive"`
+}
+
+type WhatIfCourierDto struct {
+	CourierType  string   `json:"courier_type" validate:"required,oneof=FOOT BIKE AUTO"`
+	Regions      []int64  `json:"regions" validate:"required"`
+	WorkingHours []string `json:"working_hours" validate:"required,dive,hh_mm_interval"`
+}
+
+type WhatIfOrderDto struct {
+	Weight        float64  `json:"weight" validate:"required"`
+	Regions       int64    `json:"regions" validate:"required"`
+	DeliveryHours []string `json:"delivery_hours" validate:"required,dive,hh_mm_interval"`
+	Cost          int64    `json:"cost" validate:"required"`
+}
+
+// AssignmentExplanationDto explains why an order is unassigned, reason fields are empty for an assigned order.
+type AssignmentExplanationDto struct {
+	OrderId       int64            `json:"order_id"`
+	Assigned      bool             `json:"assigned"`
+	GroupOrderId  *int64           `json:"group_order_id,omitempty"`
+	Date          string           `json:"date,omitempty"`
+	Strategy      string           `json:"strategy,omitempty"`
+	Reason        string           `json:"reason,omitempty"`
+	NearestMisses []NearestMissDto `json:"nearest_misses,omitempty"`
+}
+
+type NearestMissDto struct {
+	CourierId int64  `json:"courier_id"`
+	Reason    string `json:"reason"`
+}
+
+type AssignmentOverrideDto struct {
+	OverrideId        int64  `json:"override_id"`
+	OrderId           int64  `json:"order_id"`
+	CourierId         int64  `json:"courier_id"`
+	PreviousCourierId *int64 `json:"previous_courier_id,omitempty"`
+	GroupOrderId      int64  `json:"group_order_id"`
+	Date              string `json:"date"`
+	Forced            bool   `json:"forced"`
+	CreatedAt         string `json:"created_at"`
+}
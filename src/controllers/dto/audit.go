@@ -0,0 +1,21 @@
+package dto
+
+import "encoding/json"
+
+type AuditEntryDto struct {
+	Id     int64  `json:"id"`
+	Actor  string `json:"actor"`
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	// EntityIds are the path params of the call
+	EntityIds  map[string]string `json:"entity_ids"`
+	EntityType *string           `json:"entity_type,omitempty"`
+	EntityId   *int64            `json:"entity_id,omitempty"`
+	Before     json.RawMessage   `json:"before,omitempty"`
+	After      json.RawMessage   `json:"after,omitempty"`
+	// Diff maps every changed field to its before and after values
+	Diff      json.RawMessage `json:"diff,omitempty"`
+	RequestId string          `json:"request_id"`
+	Status    int             `json:"status"`
+	CreatedAt string          `json:"created_at"`
+}
@@ -19,6 +19,8 @@ type CourierDto struct {
 	CourierType  string   `json:"courier_type" validate:"required,oneof=FOOT BIKE AUTO"`
 	Regions      []int64  `json:"regions" validate:"required"`
 	WorkingHours []string `json:"working_hours" validate:"required,hh_mm_interval"`
+	Active       bool     `json:"active"`
+	Archived     bool     `json:"archived"`
 }
 
 type GetCouriersResponse struct {
@@ -30,8 +32,10 @@ type GetCouriersResponse struct {
 type GetCourierMetaInfoResponse struct {
 	CourierId    int64    `json:"courier_id" validate:"required"`
 	CourierType  string   `json:"courier_type" validate:"required,oneof=FOOT BIKE AUTO"`
-	Regions      []int32  `json:"regions" validate:"required"`
+	Regions      []int64  `json:"regions" validate:"required"`
 	WorkingHours []string `json:"working_hours" validate:"required,hh_mm_interval"`
-	Rating       int32    `json:"rating"`
-	Earnings     int32    `json:"earnings"`
+	Rating       *int32   `json:"rating,omitempty"`
+	Earnings     *int32   `json:"earnings,omitempty"`
+	// Quality is the average customer feedback score of the completed orders
+	Quality *float64 `json:"quality,omitempty"`
 }
@@ -0,0 +1,46 @@
+package dto
+
+type CourierLedgerResponse struct {
+	CourierId int64            `json:"courier_id"`
+	Balance   int64            `json:"balance"`
+	Entries   []LedgerEntryDto `json:"entries"`
+	Limit     int32            `json:"limit"`
+	Offset    int32            `json:"offset"`
+}
+
+type LedgerEntryDto struct {
+	EntryId   int64   `json:"entry_id"`
+	CourierId int64   `json:"courier_id"`
+	Kind      string  `json:"kind"`
+	Reason    string  `json:"reason,omitempty"`
+	Amount    int64   `json:"amount"`
+	OrderId   *int64  `json:"order_id,omitempty"`
+	Comment   *string `json:"comment,omitempty"`
+	Date      string  `json:"date"`
+	PeriodId  *int64  `json:"period_id,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// CreateLedgerEntryDto adds a bonus, penalty or payout, the amount is positive for every kind.
+type CreateLedgerEntryDto struct {
+	Kind    string  `json:"kind" validate:"required,oneof=bonus penalty payout"`
+	Reason  string  `json:"reason,omitempty"`
+	Amount  int64   `json:"amount" validate:"required,gt=0"`
+	OrderId *int64  `json:"order_id,omitempty"`
+	Comment *string `json:"comment,omitempty"`
+	// Date (YYYY-MM-DD) defaults to today
+	Date string `json:"date,omitempty"`
+}
+
+type ClosePeriodRequest struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+type PayoutPeriodDto struct {
+	PeriodId int64            `json:"period_id"`
+	From     string           `json:"from"`
+	To       string           `json:"to"`
+	ClosedAt string           `json:"closed_at"`
+	Payouts  []LedgerEntryDto `json:"payouts"`
+}
@@ -1,23 +1,48 @@
 package dto
 
+import "encoding/json"
+
 type CreateOrderRequest struct {
 	Orders []CreateOrderDto `json:"orders" validate:"required"`
 }
 
+// CreateOrderDto needs either regions or lat and lon, the region is resolved from the coordinates.
 type CreateOrderDto struct {
 	Weight        float64  `json:"weight" validate:"required"`
-	Regions       int64    `json:"regions" validate:"required"`
+	Regions       int64    `json:"regions,omitempty"`
+	Lat           *float64 `json:"lat,omitempty"`
+	Lon           *float64 `json:"lon,omitempty"`
 	DeliveryHours []string `json:"delivery_hours" validate:"required"`
-	Cost          int64    `json:"cost" validate:"required"`
+	// Cost is calculated by the current tariff if omitted, an explicit 0 is kept
+	Cost *int64 `json:"cost,omitempty" validate:"omitempty,gte=0"`
+	// Priority orders are assigned first, higher first
+	Priority int `json:"priority,omitempty" validate:"gte=0"`
+	// PromisedBy is the RFC3339 time the delivery is promised by
+	PromisedBy *string `json:"promised_by,omitempty"`
+	// DeliveryDate (YYYY-MM-DD) defaults to the creation date
+	DeliveryDate *string `json:"delivery_date,omitempty"`
 }
 
 type OrderDto struct {
 	OrderId       int64    `json:"order_id" validate:"required"`
 	Weight        float64  `json:"weight" validate:"required"`
 	Regions       int64    `json:"regions" validate:"required"`
+	Lat           *float64 `json:"lat,omitempty"`
+	Lon           *float64 `json:"lon,omitempty"`
 	DeliveryHours []string `json:"delivery_hours" validate:"required"`
+	DeliveryDate  string   `json:"delivery_date"`
 	Cost          int64    `json:"cost" validate:"required"`
 	CompletedTime *string  `json:"completed_time,omitempty"`
+	// PlannedArrival is the planned delivery time (HH:MM) of an order in a group order
+	PlannedArrival *string `json:"planned_arrival,omitempty"`
+	Pinned         bool    `json:"pinned,omitempty"`
+	Priority       int     `json:"priority,omitempty"`
+	PromisedBy     *string `json:"promised_by,omitempty"`
+	// LatenessMinutes is how late the order was completed, 0 if on time
+	LatenessMinutes *int   `json:"lateness_minutes,omitempty"`
+	TariffVersion   *int64 `json:"tariff_version,omitempty"`
+	// LatestEvent is embedded on request only
+	LatestEvent *OrderEventDto `json:"latest_event,omitempty"`
 }
 
 type CompleteOrderRequestDto struct {
@@ -29,3 +54,45 @@ type CompleteOrder struct {
 	OrderId      int64  `json:"order_id" validate:"required"`
 	CompleteTime string `json:"complete_time" validate:"required"` //todo add validation
 }
+
+type LateRatesResponse struct {
+	Couriers []CourierLateRateDto `json:"couriers"`
+	Regions  []RegionLateRateDto  `json:"regions"`
+}
+
+type CourierLateRateDto struct {
+	CourierId int64   `json:"courier_id"`
+	Completed int64   `json:"completed"`
+	Late      int64   `json:"late"`
+	LateRate  float64 `json:"late_rate"`
+}
+
+type RegionLateRateDto struct {
+	RegionId  int64   `json:"region_id"`
+	Completed int64   `json:"completed"`
+	Late      int64   `json:"late"`
+	LateRate  float64 `json:"late_rate"`
+}
+
+type CreateOrderFeedbackDto struct {
+	Score   int     `json:"score" validate:"required,min=1,max=5"`
+	Comment *string `json:"comment,omitempty"`
+}
+
+type OrderFeedbackDto struct {
+	OrderId   int64   `json:"order_id"`
+	CourierId int64   `json:"courier_id"`
+	Score     int     `json:"score"`
+	Comment   *string `json:"comment,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// OrderEventDto is an entry of the order history, the payload depends on the type.
+type OrderEventDto struct {
+	EventId   int64           `json:"event_id"`
+	OrderId   int64           `json:"order_id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt string          `json:"created_at"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import "encoding/json"
+
+type CreateRegionDto struct {
+	RegionId int64  `json:"region_id" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required"`
+	TimeZone string `json:"time_zone" validate:"required"`
+	Active   *bool  `json:"active,omitempty"`
+	//Boundary is a GeoJSON Polygon or MultiPolygon geometry
+	Boundary json.RawMessage `json:"boundary,omitempty"`
+}
+
+type UpdateRegionDto struct {
+	Name     string          `json:"name" validate:"required"`
+	TimeZone string          `json:"time_zone" validate:"required"`
+	Active   bool            `json:"active"`
+	Boundary json.RawMessage `json:"boundary,omitempty"`
+}
+
+type RegionDto struct {
+	RegionId int64           `json:"region_id"`
+	Name     string          `json:"name"`
+	TimeZone string          `json:"time_zone"`
+	Active   bool            `json:"active"`
+	Boundary json.RawMessage `json:"boundary,omitempty"`
+}
+
+type RegionFeatureCollection struct {
+	Type     string          `json:"type"`
+	Features []RegionFeature `json:"features"`
+}
+
+type RegionFeature struct {
+	Type       string                  `json:"type"`
+	Id         int64                   `json:"id"`
+	Geometry   json.RawMessage         `json:"geometry"`
+	Properties RegionFeatureProperties `json:"properties"`
+}
+
+type RegionFeatureProperties struct {
+	Name     string `json:"name"`
+	TimeZone string `json:"time_zone"`
+	Active   bool   `json:"active"`
+}
+
+type RegionNeighborDto struct {
+	RegionId int64            `json:"region_id"`
+	Minutes  map[string]int64 `json:"minutes"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+type SetCourierScheduleRequest struct {
+	Weekdays []CourierScheduleDto `json:"weekdays" validate:"required,dive"`
+}
+
+type CourierScheduleDto struct {
+	Weekday      int      `json:"weekday" validate:"required,min=1,max=7"`
+	WorkingHours []string `json:"working_hours" validate:"required,dive,hh_mm_interval"`
+}
+
+type CourierScheduleResponse struct {
+	CourierId int64                `json:"courier_id"`
+	Weekdays  []CourierScheduleDto `json:"weekdays"`
+}
+
+type CreateScheduleOverrideDto struct {
+	Date         string   `json:"date" validate:"required,datetime=2006-01-02"`
+	Kind         string   `json:"kind" validate:"required,oneof=DAY_OFF SICK_DAY EXTRA_SHIFT"`
+	WorkingHours []string `json:"working_hours" validate:"dive,hh_mm_interval"`
+	Comment      *string  `json:"comment,omitempty"`
+}
+
+type ScheduleOverrideDto struct {
+	OverrideId   int64    `json:"override_id"`
+	CourierId    int64    `json:"courier_id"`
+	Date         string   `json:"date"`
+	Kind         string   `json:"kind"`
+	WorkingHours []string `json:"working_hours"`
+	Comment      *string  `json:"comment,omitempty"`
+}
+
+type CalendarDayDto struct {
+	Date         string                `json:"date"`
+	WorkingHours []string              `json:"working_hours"`
+	Overrides    []ScheduleOverrideDto `json:"overrides"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+type ShiftDto struct {
+	ShiftId   int64   `json:"shift_id"`
+	CourierId int64   `json:"courier_id"`
+	StartedAt string  `json:"started_at"`
+	EndedAt   *string `json:"ended_at,omitempty"`
+}
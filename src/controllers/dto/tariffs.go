@@ -0,0 +1,31 @@
+package dto
+
+type CreateTariffDto struct {
+	BasePrice             int64                `json:"base_price" validate:"gte=0"`
+	ZonePrices            []TariffZonePriceDto `json:"zone_prices" validate:"dive"`
+	PerKgPrice            int64                `json:"per_kg_price" validate:"gte=0"`
+	NarrowWindowMinutes   int                  `json:"narrow_window_minutes" validate:"gte=0"`
+	NarrowWindowSurcharge int64                `json:"narrow_window_surcharge" validate:"gte=0"`
+	Surges                []TariffSurgeDto     `json:"surges" validate:"dive"`
+}
+
+type TariffDto struct {
+	Version               int64                `json:"version"`
+	BasePrice             int64                `json:"base_price"`
+	ZonePrices            []TariffZonePriceDto `json:"zone_prices"`
+	PerKgPrice            int64                `json:"per_kg_price"`
+	NarrowWindowMinutes   int                  `json:"narrow_window_minutes"`
+	NarrowWindowSurcharge int64                `json:"narrow_window_surcharge"`
+	Surges                []TariffSurgeDto     `json:"surges"`
+	CreatedAt             string               `json:"created_at"`
+}
+
+type TariffZonePriceDto struct {
+	RegionId  int64 `json:"region_id" validate:"required"`
+	BasePrice int64 `json:"base_price" validate:"gte=0"`
+}
+
+type TariffSurgeDto struct {
+	Interval   string  `json:"interval" validate:"required,hh_mm_interval"`
+	Multiplier float64 `json:"multiplier" validate:"gt=0"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import "encoding/json"
+
+// CreateWebhookDto subscribes a URL to event types, a secret is generated if it is omitted.
+type CreateWebhookDto struct {
+	Url        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+type UpdateWebhookDto struct {
+	Url        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+	Active     bool     `json:"active"`
+}
+
+type WebhookDto struct {
+	WebhookId  int64    `json:"webhook_id"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	// Secret signs deliveries, it is returned on creation only
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDeliveryDto struct {
+	DeliveryId     int64           `json:"delivery_id"`
+	WebhookId      int64           `json:"webhook_id"`
+	EventId        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *string         `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	DeliveredAt    *string         `json:"delivered_at,omitempty"`
+}
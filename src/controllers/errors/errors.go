@@ -11,6 +11,7 @@ const (
 	NotFound
 	TooManyRequests
 	NotImplemented
+	Forbidden
 )
 
 type ErrorType uint
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/outbox"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	getEventStream = handlerName(iota)
+	getWebSocket
+)
+
+// lastEventIdHeader is sent by an EventSource reconnecting after the last event it got.
+const lastEventIdHeader = "Last-Event-ID"
+
+type EventController struct {
+	eventStream  *services.EventStream
+	keepAlive    time.Duration
+	rateLimiters map[handlerName]*rate_limiter.RateLimiter
+}
+
+// NewEventController streams events, an idle Server-Sent Events stream gets a comment every keepAlive.
+func NewEventController(s *services.EventStream, keepAlive time.Duration) *EventController {
+	return &EventController{
+		eventStream: s,
+		keepAlive:   keepAlive,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getEventStream: rate_limiter.NewRateLimiter(),
+			getWebSocket:   rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+// GetEventStream streams order and courier events as Server-Sent Events, optionally of a region or a courier.
+// A client resumes after the event of the Last-Event-ID header or the last_event_id query param,
+// it gets only new events otherwise.
+func (c *EventController) GetEventStream(ctx echo.Context) error {
+	if !c.rateLimiters[getEventStream].RegisterCall() {
+		return cerrors.TooManyRequests.New("get event stream method overloaded")
+	}
+
+	subscription, err := c.subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	requestCtx := ctx.Request().Context()
+	for {
+		waitCtx, cancel := context.WithTimeout(requestCtx, c.keepAlive)
+		events, err := subscription.Next(waitCtx)
+		cancel()
+		if requestCtx.Err() != nil {
+			return nil
+		}
+		if err == context.DeadlineExceeded {
+			if _, err = fmt.Fprint(response, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			response.Flush()
+			continue
+		}
+		if err != nil {
+			ctx.Logger().Errorf("event stream failed: %s", err.Error())
+			return nil
+		}
+
+		for _, event := range events {
+			data, err := json.Marshal(outbox.NewMessage(&event.OutboxEvent))
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(response, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data); err != nil {
+				return nil
+			}
+		}
+		response.Flush()
+	}
+}
+
+// GetWebSocket streams the events of GetEventStream as JSON messages over a WebSocket,
+// resuming after the last_event_id query param if it is set. Messages from the client are ignored.
+func (c *EventController) GetWebSocket(ctx echo.Context) error {
+	if !c.rateLimiters[getWebSocket].RegisterCall() {
+		return cerrors.TooManyRequests.New("get websocket method overloaded")
+	}
+
+	subscription, err := c.subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		streamCtx, cancel := context.WithCancel(ws.Request().Context())
+		defer cancel()
+		// the stream ends once the client closes the connection
+		go func() {
+			var message string
+			for websocket.Message.Receive(ws, &message) == nil {
+			}
+			cancel()
+		}()
+
+		for {
+			events, err := subscription.Next(streamCtx)
+			if err != nil {
+				if streamCtx.Err() == nil {
+					ctx.Logger().Errorf("websocket stream failed: %s", err.Error())
+				}
+				return
+			}
+			for _, event := range events {
+				if err = websocket.JSON.Send(ws, outbox.NewMessage(&event.OutboxEvent)); err != nil {
+					return
+				}
+			}
+		}
+	}}.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+func (c *EventController) subscribe(ctx echo.Context) (*services.Subscription, error) {
+	filter := services.EventFilter{}
+	if ctx.QueryParam("region") != "" {
+		region, err := parseInt64QueryParam(ctx, "region", 0)
+		if err != nil {
+			return nil, err
+		}
+		filter.Region = &region
+	}
+	if ctx.QueryParam("courier_id") != "" {
+		courierId, err := parseInt64QueryParam(ctx, "courier_id", 0)
+		if err != nil {
+			return nil, err
+		}
+		filter.CourierId = &courierId
+	}
+
+	var afterId *int64
+	lastEventId := ctx.Request().Header.Get(lastEventIdHeader)
+	if lastEventId == "" {
+		lastEventId = ctx.QueryParam("last_event_id")
+	}
+	if lastEventId != "" {
+		id, err := strconv.ParseInt(lastEventId, 10, 64)
+		if err != nil {
+			return nil, cerrors.BadRequest.Wrapf(err, "cannot parse last event id, got '%s'", lastEventId)
+		}
+		afterId = &id
+	}
+
+	return c.eventStream.Subscribe(filter, afterId)
+}
@@ -1,3 +1,78 @@
 package controllers
 
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"crypto/subtle"
+	"github.com/labstack/echo/v4"
+	"strconv"
+	"time"
+)
+
 type handlerName uint
+
+func parseInt64QueryParam(ctx echo.Context, name string, defaultValue int64) (int64, error) {
+	str := ctx.QueryParam(name)
+	if str == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, cerrors.BadRequest.Wrapf(err, "cannot parse query param '%s', got '%s'", name, str)
+	}
+	return value, nil
+}
+
+func parseInt64PathParam(ctx echo.Context, name string) (int64, error) {
+	str := ctx.Param(name)
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, cerrors.BadRequest.Wrapf(err, "cannot parse path param '%s', got '%s'", name, str)
+	}
+	return value, nil
+}
+
+// actorHeader names who makes the request, it is recorded in the order history.
+const actorHeader = "X-Actor"
+
+// defaultActor is the actor of requests without the actor header.
+const defaultActor = "api"
+
+// adminTokenHeader carries the configured admin token of requests by admins.
+const adminTokenHeader = "X-Admin-Token"
+
+func actorOf(ctx echo.Context) string {
+	if actor := ctx.Request().Header.Get(actorHeader); actor != "" {
+		return actor
+	}
+	return defaultActor
+}
+
+// isAdmin reports whether the request carries the admin token, nobody is an admin without a configured token.
+func isAdmin(ctx echo.Context, adminToken string) bool {
+	token := ctx.Request().Header.Get(adminTokenHeader)
+	return adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+func toCourierDto(courier *model.Courier) dto.CourierDto {
+	return dto.CourierDto{
+		CourierId:    courier.CourierId,
+		CourierType:  courier.CourierType,
+		Regions:      courier.Regions,
+		WorkingHours: courier.WorkingHours,
+		Active:       courier.Active,
+		Archived:     courier.Archived,
+	}
+}
+
+const dateLayout = "2006-01-02"
+
+func parseDateQueryParam(ctx echo.Context, name string) (time.Time, error) {
+	str := ctx.QueryParam(name)
+	date, err := time.Parse(dateLayout, str)
+	if err != nil {
+		return time.Time{}, cerrors.BadRequest.Wrapf(err, "cannot parse query param '%s', got '%s'", name, str)
+	}
+	return date, nil
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const (
+	getCourierLedger = handlerName(iota)
+	postCourierLedgerEntry
+	postClosePeriod
+	getPayoutPeriod
+)
+
+type LedgerController struct {
+	ledgerService *services.LedgerService
+	rateLimiters  map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewLedgerController(s *services.LedgerService) *LedgerController {
+	return &LedgerController{
+		ledgerService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getCourierLedger:       rate_limiter.NewRateLimiter(),
+			postCourierLedgerEntry: rate_limiter.NewRateLimiter(),
+			postClosePeriod:        rate_limiter.NewRateLimiter(),
+			getPayoutPeriod:        rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+func (c *LedgerController) GetCourierLedger(ctx echo.Context) error {
+	if !c.rateLimiters[getCourierLedger].RegisterCall() {
+		return cerrors.TooManyRequests.New("get courier ledger method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	entries, balance, err := c.ledgerService.GetLedger(id, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	response := dto.CourierLedgerResponse{
+		CourierId: id,
+		Balance:   balance,
+		Entries:   make([]dto.LedgerEntryDto, len(entries)),
+		Limit:     int32(limit),
+		Offset:    int32(offset),
+	}
+	for i := 0; i < len(entries); i++ {
+		response.Entries[i] = toLedgerEntryDto(entries[i])
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *LedgerController) PostCourierLedgerEntry(ctx echo.Context) error {
+	if !c.rateLimiters[postCourierLedgerEntry].RegisterCall() {
+		return cerrors.TooManyRequests.New("post courier ledger entry method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	request := new(dto.CreateLedgerEntryDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse create ledger entry request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid create ledger entry request")
+	}
+
+	date, err := time.Parse(dateLayout, time.Now().UTC().Format(dateLayout))
+	if err != nil {
+		return err
+	}
+	if request.Date != "" {
+		if date, err = time.Parse(dateLayout, request.Date); err != nil {
+			return cerrors.BadRequest.Wrapf(err, "cannot parse entry date, got '%s'", request.Date)
+		}
+	}
+
+	entry, err := c.ledgerService.CreateEntry(service_data.NewLedgerEntryData{
+		CourierId: id,
+		Kind:      request.Kind,
+		Reason:    request.Reason,
+		Amount:    request.Amount,
+		OrderId:   request.OrderId,
+		Comment:   request.Comment,
+		Date:      date,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toLedgerEntryDto(entry))
+}
+
+// PostClosePeriod pays out the balances of [from, to) and freezes the entries of the period.
+func (c *LedgerController) PostClosePeriod(ctx echo.Context) error {
+	if !c.rateLimiters[postClosePeriod].RegisterCall() {
+		return cerrors.TooManyRequests.New("post close period method overloaded")
+	}
+
+	request := new(dto.ClosePeriodRequest)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse close period request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid close period request")
+	}
+	from, err := time.Parse(dateLayout, request.From)
+	if err != nil {
+		return cerrors.BadRequest.Wrapf(err, "cannot parse 'from', got '%s'", request.From)
+	}
+	to, err := time.Parse(dateLayout, request.To)
+	if err != nil {
+		return cerrors.BadRequest.Wrapf(err, "cannot parse 'to', got '%s'", request.To)
+	}
+
+	period, err := c.ledgerService.ClosePeriod(from, to)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toPayoutPeriodDto(period))
+}
+
+func (c *LedgerController) GetPayoutPeriod(ctx echo.Context) error {
+	if !c.rateLimiters[getPayoutPeriod].RegisterCall() {
+		return cerrors.TooManyRequests.New("get payout period method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "period_id")
+	if err != nil {
+		return err
+	}
+
+	period, err := c.ledgerService.GetPeriodById(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toPayoutPeriodDto(period))
+}
+
+func toLedgerEntryDto(entry *model.LedgerEntry) dto.LedgerEntryDto {
+	return dto.LedgerEntryDto{
+		EntryId:   entry.EntryId,
+		CourierId: entry.CourierId,
+		Kind:      entry.Kind,
+		Reason:    entry.Reason,
+		Amount:    entry.Amount,
+		OrderId:   entry.OrderId,
+		Comment:   entry.Comment,
+		Date:      entry.Date.Format(dateLayout),
+		PeriodId:  entry.PeriodId,
+		CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toPayoutPeriodDto(period *model.PayoutPeriod) dto.PayoutPeriodDto {
+	response := dto.PayoutPeriodDto{
+		PeriodId: period.PeriodId,
+		From:     period.From.Format(dateLayout),
+		To:       period.To.Format(dateLayout),
+		ClosedAt: period.ClosedAt.Format(time.RFC3339),
+		Payouts:  make([]dto.LedgerEntryDto, len(period.Payouts)),
+	}
+	for i, payout := range period.Payouts {
+		response.Payouts[i] = toLedgerEntryDto(payout)
+	}
+	return response
+}
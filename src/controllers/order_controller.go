@@ -5,10 +5,11 @@ import (
 	cerrors "Ya.SumSchool23/controllers/errors"
 	"Ya.SumSchool23/rate_limiter"
 	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
 	"Ya.SumSchool23/services/service_data"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"strconv"
+	"time"
 )
 
 const (
@@ -16,8 +17,15 @@ const (
 	getOrderById
 	postOrders
 	postOrdersComplete
+	getOrdersLateRates
+	postOrderFeedback
+	getOrderFeedback
+	getOrderHistory
 )
 
+// embedLatestEvent is the embed query param value that adds the latest event to an order.
+const embedLatestEvent = "latest_event"
+
 type OrderController struct {
 	orderService *services.OrderService
 	rateLimiters map[handlerName]*rate_limiter.RateLimiter
@@ -31,6 +39,10 @@ func NewOrderController(s *services.OrderService) *OrderController {
 			getOrderById:       rate_limiter.NewRateLimiter(),
 			postOrders:         rate_limiter.NewRateLimiter(),
 			postOrdersComplete: rate_limiter.NewRateLimiter(),
+			getOrdersLateRates: rate_limiter.NewRateLimiter(),
+			postOrderFeedback:  rate_limiter.NewRateLimiter(),
+			getOrderFeedback:   rate_limiter.NewRateLimiter(),
+			getOrderHistory:    rate_limiter.NewRateLimiter(),
 		},
 	}
 }
@@ -40,43 +52,32 @@ func (c *OrderController) GetOrders(ctx echo.Context) error {
 		return cerrors.TooManyRequests.New("get orders method overloaded")
 	}
 
-	limitStr := ctx.QueryParam("limit")
-	var limit int64
-	if limitStr == "" {
-		limit = 1
-	} else {
-		l, err := strconv.ParseInt(limitStr, 10, 64)
-		if err != nil {
-			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'limit', got '%s'", limitStr)
-		}
-		limit = l
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
 	}
 
-	offsetStr := ctx.QueryParam("offset")
-	var offset int64
-	if offsetStr == "" {
-		offset = 0
-	} else {
-		off, err := strconv.ParseInt(offsetStr, 10, 64)
+	var deliveryDate *time.Time
+	if ctx.QueryParam("delivery_date") != "" {
+		date, err := parseDateQueryParam(ctx, "delivery_date")
 		if err != nil {
-			return cerrors.BadRequest.Wrapf(err, "cannot parse query param 'offset', got '%s'", offsetStr)
+			return err
 		}
-		offset = off
+		deliveryDate = &date
 	}
 
-	orders, err := c.orderService.GetOrders(limit, offset)
+	orders, err := c.orderService.GetOrders(limit, offset, deliveryDate)
 	if err != nil {
 		return err
 	}
 
 	ordersDto := make([]dto.OrderDto, len(orders))
 	for i := 0; i < len(orders); i++ {
-		ordersDto[i].OrderId = orders[i].OrderId
-		ordersDto[i].Weight = orders[i].Weight
-		ordersDto[i].Regions = orders[i].Regions
-		ordersDto[i].DeliveryHours = orders[i].DeliveryHours
-		ordersDto[i].Cost = orders[i].Cost
-		ordersDto[i].CompletedTime = orders[i].CompletedTime
+		ordersDto[i] = toOrderDto(orders[i])
 	}
 	return ctx.JSON(http.StatusOK, ordersDto)
 }
@@ -86,26 +87,32 @@ func (c *OrderController) GetOrderById(ctx echo.Context) error {
 		return cerrors.TooManyRequests.New("get order by id method overloaded")
 	}
 
-	idStr := ctx.Param("order_id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := parseInt64PathParam(ctx, "order_id")
 	if err != nil {
-		return cerrors.BadRequest.Wrapf(err, "cannot parse path param 'order_id', got '%s'", idStr)
+		return err
+	}
+
+	embed := ctx.QueryParam("embed")
+	if embed != "" && embed != embedLatestEvent {
+		return cerrors.BadRequest.Newf("unknown embed '%s', expected '%s'", embed, embedLatestEvent)
 	}
 
 	order, err := c.orderService.GetOrderById(id)
 	if err != nil {
 		return err
 	}
-
-	orderDto := dto.OrderDto{
-		OrderId:       order.OrderId,
-		Weight:        order.Weight,
-		Regions:       order.Regions,
-		DeliveryHours: order.DeliveryHours,
-		Cost:          order.Cost,
-		CompletedTime: order.CompletedTime,
+	response := toOrderDto(order)
+	if embed == embedLatestEvent {
+		event, err := c.orderService.GetLatestEvent(id)
+		if err != nil {
+			return err
+		}
+		if event != nil {
+			eventDto := toOrderEventDto(event)
+			response.LatestEvent = &eventDto
+		}
 	}
-	return ctx.JSON(http.StatusOK, orderDto)
+	return ctx.JSON(http.StatusOK, response)
 
 }
 
@@ -126,23 +133,35 @@ func (c *OrderController) PostOrders(ctx echo.Context) error {
 	for i := 0; i < len(data); i++ {
 		data[i].Weight = createOrderRequest.Orders[i].Weight
 		data[i].Regions = createOrderRequest.Orders[i].Regions
+		data[i].Lat = createOrderRequest.Orders[i].Lat
+		data[i].Lon = createOrderRequest.Orders[i].Lon
 		data[i].DeliveryHours = createOrderRequest.Orders[i].DeliveryHours
 		data[i].Cost = createOrderRequest.Orders[i].Cost
+		data[i].Priority = createOrderRequest.Orders[i].Priority
+		if promisedBy := createOrderRequest.Orders[i].PromisedBy; promisedBy != nil {
+			promised, err := time.Parse(time.RFC3339, *promisedBy)
+			if err != nil {
+				return cerrors.BadRequest.Wrapf(err, "cannot parse promised_by, got '%s'", *promisedBy)
+			}
+			data[i].PromisedBy = &promised
+		}
+		if deliveryDate := createOrderRequest.Orders[i].DeliveryDate; deliveryDate != nil {
+			date, err := time.Parse(dateLayout, *deliveryDate)
+			if err != nil {
+				return cerrors.BadRequest.Wrapf(err, "cannot parse delivery_date, got '%s'", *deliveryDate)
+			}
+			data[i].DeliveryDate = &date
+		}
 	}
 
-	createdOrders, err := c.orderService.CreateOrders(data)
+	createdOrders, err := c.orderService.CreateOrders(ctx.Request().Context(), data)
 	if err != nil {
 		return err
 	}
 
 	response := make([]dto.OrderDto, len(createdOrders))
 	for i := 0; i < len(createdOrders); i++ {
-		response[i].OrderId = createdOrders[i].OrderId
-		response[i].Weight = createdOrders[i].Weight
-		response[i].Regions = createdOrders[i].Regions
-		response[i].DeliveryHours = createdOrders[i].DeliveryHours
-		response[i].Cost = createdOrders[i].Cost
-		response[i].CompletedTime = createdOrders[i].CompletedTime
+		response[i] = toOrderDto(createdOrders[i])
 	}
 	return ctx.JSON(http.StatusOK, response)
 
@@ -168,19 +187,178 @@ func (c *OrderController) PostOrdersComplete(ctx echo.Context) error {
 		data[i].CompleteTime = createCompleteOrderRequests.CompleteInfo[i].CompleteTime
 	}
 
-	createCompleteOrders, err := c.orderService.CreateCompleteOrder(data)
+	createCompleteOrders, err := c.orderService.CreateCompleteOrder(ctx.Request().Context(), data)
 	if err != nil {
 		return err
 	}
 
 	response := make([]dto.OrderDto, len(createCompleteOrders))
 	for i := 0; i < len(createCompleteOrders); i++ {
-		response[i].OrderId = createCompleteOrders[i].OrderId
-		response[i].Weight = createCompleteOrders[i].Weight
-		response[i].Regions = createCompleteOrders[i].Regions
-		response[i].DeliveryHours = createCompleteOrders[i].DeliveryHours
-		response[i].Cost = createCompleteOrders[i].Cost
-		response[i].CompletedTime = createCompleteOrders[i].CompletedTime
+		response[i] = toOrderDto(createCompleteOrders[i])
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetOrdersLateRates returns the share of orders completed late in [startDate, endDate) per courier and per region.
+func (c *OrderController) GetOrdersLateRates(ctx echo.Context) error {
+	if !c.rateLimiters[getOrdersLateRates].RegisterCall() {
+		return cerrors.TooManyRequests.New("get orders late rates method overloaded")
+	}
+
+	startDate, err := parseDateQueryParam(ctx, "startDate")
+	if err != nil {
+		return err
+	}
+	endDate, err := parseDateQueryParam(ctx, "endDate")
+	if err != nil {
+		return err
+	}
+	if !endDate.After(startDate) {
+		return cerrors.BadRequest.Newf("'endDate' must be after 'startDate', got '%s' and '%s'",
+			endDate.Format(dateLayout), startDate.Format(dateLayout))
+	}
+
+	couriers, regions, err := c.orderService.GetLateRates(startDate, endDate)
+	if err != nil {
+		return err
+	}
+
+	response := dto.LateRatesResponse{
+		Couriers: make([]dto.CourierLateRateDto, len(couriers)),
+		Regions:  make([]dto.RegionLateRateDto, len(regions)),
+	}
+	for i, rate := range couriers {
+		response.Couriers[i] = dto.CourierLateRateDto{
+			CourierId: rate.Id, Completed: rate.Completed, Late: rate.Late, LateRate: lateRate(rate),
+		}
+	}
+	for i, rate := range regions {
+		response.Regions[i] = dto.RegionLateRateDto{
+			RegionId: rate.Id, Completed: rate.Completed, Late: rate.Late, LateRate: lateRate(rate),
+		}
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// PostOrderFeedback stores the customer score of a completed order, once per order.
+func (c *OrderController) PostOrderFeedback(ctx echo.Context) error {
+	if !c.rateLimiters[postOrderFeedback].RegisterCall() {
+		return cerrors.TooManyRequests.New("post order feedback method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "order_id")
+	if err != nil {
+		return err
+	}
+
+	request := new(dto.CreateOrderFeedbackDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse create order feedback request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid create order feedback request")
+	}
+
+	feedback, err := c.orderService.CreateFeedback(service_data.NewOrderFeedbackData{
+		OrderId: id,
+		Score:   request.Score,
+		Comment: request.Comment,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toOrderFeedbackDto(feedback))
+}
+
+func (c *OrderController) GetOrderFeedback(ctx echo.Context) error {
+	if !c.rateLimiters[getOrderFeedback].RegisterCall() {
+		return cerrors.TooManyRequests.New("get order feedback method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "order_id")
+	if err != nil {
+		return err
+	}
+
+	feedback, err := c.orderService.GetFeedback(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toOrderFeedbackDto(feedback))
+}
+
+func toOrderFeedbackDto(feedback *model.OrderFeedback) dto.OrderFeedbackDto {
+	return dto.OrderFeedbackDto{
+		OrderId:   feedback.OrderId,
+		CourierId: feedback.CourierId,
+		Score:     feedback.Score,
+		Comment:   feedback.Comment,
+		CreatedAt: feedback.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func lateRate(rate *model.LateRate) float64 {
+	if rate.Completed == 0 {
+		return 0
+	}
+	return float64(rate.Late) / float64(rate.Completed)
+}
+
+// GetOrderHistory returns every transition of the order, oldest first.
+func (c *OrderController) GetOrderHistory(ctx echo.Context) error {
+	if !c.rateLimiters[getOrderHistory].RegisterCall() {
+		return cerrors.TooManyRequests.New("get order history method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "order_id")
+	if err != nil {
+		return err
+	}
+
+	events, err := c.orderService.GetHistory(id)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.OrderEventDto, len(events))
+	for i, event := range events {
+		response[i] = toOrderEventDto(event)
 	}
 	return ctx.JSON(http.StatusOK, response)
 }
+
+func toOrderEventDto(event *model.OrderEvent) dto.OrderEventDto {
+	return dto.OrderEventDto{
+		EventId:   event.EventId,
+		OrderId:   event.OrderId,
+		Type:      event.Type,
+		Actor:     event.Actor,
+		Payload:   event.Payload,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toOrderDto(order *model.Order) dto.OrderDto {
+	var promisedBy *string
+	if order.PromisedBy != nil {
+		formatted := order.PromisedBy.Format(time.RFC3339)
+		promisedBy = &formatted
+	}
+	return dto.OrderDto{
+		OrderId:         order.OrderId,
+		Weight:          order.Weight,
+		Regions:         order.Regions,
+		Lat:             order.Lat,
+		Lon:             order.Lon,
+		DeliveryHours:   order.DeliveryHours,
+		DeliveryDate:    order.DeliveryDate.Format(dateLayout),
+		Cost:            order.Cost,
+		CompletedTime:   order.CompletedTime,
+		PlannedArrival:  order.PlannedArrival,
+		Pinned:          order.Pinned,
+		Priority:        order.Priority,
+		PromisedBy:      promisedBy,
+		LatenessMinutes: order.LatenessMinutes,
+		TariffVersion:   order.TariffVersion,
+	}
+}
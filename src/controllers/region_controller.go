@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+const (
+	getRegions = handlerName(iota)
+	getRegionById
+	postRegion
+	putRegion
+	deleteRegion
+	getRegionsGeoJson
+	getRegionNeighbors
+)
+
+type RegionController struct {
+	regionService  *services.RegionService
+	routingService *services.RoutingService
+	rateLimiters   map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewRegionController(s *services.RegionService, rs *services.RoutingService) *RegionController {
+	return &RegionController{
+		regionService:  s,
+		routingService: rs,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getRegions:         rate_limiter.NewRateLimiter(),
+			getRegionById:      rate_limiter.NewRateLimiter(),
+			postRegion:         rate_limiter.NewRateLimiter(),
+			putRegion:          rate_limiter.NewRateLimiter(),
+			deleteRegion:       rate_limiter.NewRateLimiter(),
+			getRegionsGeoJson:  rate_limiter.NewRateLimiter(),
+			getRegionNeighbors: rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+func (c *RegionController) GetRegions(ctx echo.Context) error {
+	if !c.rateLimiters[getRegions].RegisterCall() {
+		return cerrors.TooManyRequests.New("get regions method overloaded")
+	}
+
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	regions, err := c.regionService.GetRegions(limit, offset)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.RegionDto, len(regions))
+	for i := 0; i < len(regions); i++ {
+		response[i] = toRegionDto(regions[i])
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *RegionController) GetRegionById(ctx echo.Context) error {
+	if !c.rateLimiters[getRegionById].RegisterCall() {
+		return cerrors.TooManyRequests.New("get region by id method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "region_id")
+	if err != nil {
+		return err
+	}
+
+	region, err := c.regionService.GetRegionById(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toRegionDto(region))
+}
+
+func (c *RegionController) PostRegion(ctx echo.Context) error {
+	if !c.rateLimiters[postRegion].RegisterCall() {
+		return cerrors.TooManyRequests.New("post region method overloaded")
+	}
+
+	request := new(dto.CreateRegionDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse create region request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid create region request")
+	}
+
+	data := service_data.NewRegionData{
+		RegionId: request.RegionId,
+		Name:     request.Name,
+		TimeZone: request.TimeZone,
+		Active:   true,
+		Boundary: request.Boundary,
+	}
+	if request.Active != nil {
+		data.Active = *request.Active
+	}
+
+	region, err := c.regionService.CreateRegion(data)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toRegionDto(region))
+}
+
+func (c *RegionController) PutRegion(ctx echo.Context) error {
+	if !c.rateLimiters[putRegion].RegisterCall() {
+		return cerrors.TooManyRequests.New("put region method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "region_id")
+	if err != nil {
+		return err
+	}
+
+	request := new(dto.UpdateRegionDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse update region request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid update region request")
+	}
+
+	region, err := c.regionService.UpdateRegion(service_data.NewRegionData{
+		RegionId: id,
+		Name:     request.Name,
+		TimeZone: request.TimeZone,
+		Active:   request.Active,
+		Boundary: request.Boundary,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toRegionDto(region))
+}
+
+func (c *RegionController) DeleteRegion(ctx echo.Context) error {
+	if !c.rateLimiters[deleteRegion].RegisterCall() {
+		return cerrors.TooManyRequests.New("delete region method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "region_id")
+	if err != nil {
+		return err
+	}
+
+	if err = c.regionService.DeleteRegion(id); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.EmptyResponse{})
+}
+
+// GetRegionsGeoJson exports all regions with a boundary as a GeoJSON FeatureCollection.
+func (c *RegionController) GetRegionsGeoJson(ctx echo.Context) error {
+	if !c.rateLimiters[getRegionsGeoJson].RegisterCall() {
+		return cerrors.TooManyRequests.New("get regions geojson method overloaded")
+	}
+
+	regions, err := c.regionService.GetRegionsWithBoundary()
+	if err != nil {
+		return err
+	}
+
+	response := dto.RegionFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]dto.RegionFeature, len(regions)),
+	}
+	for i := 0; i < len(regions); i++ {
+		response.Features[i] = dto.RegionFeature{
+			Type:     "Feature",
+			Id:       regions[i].RegionId,
+			Geometry: regions[i].Boundary,
+			Properties: dto.RegionFeatureProperties{
+				Name:     regions[i].Name,
+				TimeZone: regions[i].TimeZone,
+				Active:   regions[i].Active,
+			},
+		}
+	}
+	ctx.Response().Header().Set(echo.HeaderContentType, "application/geo+json")
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetRegionNeighbors returns the directly connected regions with travel minutes per courier type.
+func (c *RegionController) GetRegionNeighbors(ctx echo.Context) error {
+	if !c.rateLimiters[getRegionNeighbors].RegisterCall() {
+		return cerrors.TooManyRequests.New("get region neighbors method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "region_id")
+	if err != nil {
+		return err
+	}
+
+	neighbors, err := c.routingService.GetNeighbors(id)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.RegionNeighborDto, len(neighbors))
+	for i := 0; i < len(neighbors); i++ {
+		response[i].RegionId = neighbors[i].To
+		response[i].Minutes = neighbors[i].Minutes
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toRegionDto(region *model.Region) dto.RegionDto {
+	return dto.RegionDto{
+		RegionId: region.RegionId,
+		Name:     region.Name,
+		TimeZone: region.TimeZone,
+		Active:   region.Active,
+		Boundary: region.Boundary,
+	}
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const maxCalendarDays = 366
+
+const (
+	getCourierSchedule = handlerName(iota)
+	putCourierSchedule
+	postScheduleOverride
+	deleteScheduleOverride
+	getCourierCalendar
+)
+
+type ScheduleController struct {
+	courierService *services.CourierService
+	rateLimiters   map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewScheduleController(s *services.CourierService) *ScheduleController {
+	return &ScheduleController{
+		courierService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getCourierSchedule:     rate_limiter.NewRateLimiter(),
+			putCourierSchedule:     rate_limiter.NewRateLimiter(),
+			postScheduleOverride:   rate_limiter.NewRateLimiter(),
+			deleteScheduleOverride: rate_limiter.NewRateLimiter(),
+			getCourierCalendar:     rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+func (c *ScheduleController) GetCourierSchedule(ctx echo.Context) error {
+	if !c.rateLimiters[getCourierSchedule].RegisterCall() {
+		return cerrors.TooManyRequests.New("get courier schedule method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	schedules, err := c.courierService.GetWeeklySchedule(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toCourierScheduleResponse(id, schedules))
+}
+
+func (c *ScheduleController) PutCourierSchedule(ctx echo.Context) error {
+	if !c.rateLimiters[putCourierSchedule].RegisterCall() {
+		return cerrors.TooManyRequests.New("put courier schedule method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	request := new(dto.SetCourierScheduleRequest)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse set courier schedule request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid set courier schedule request")
+	}
+
+	data := make([]service_data.NewCourierScheduleData, len(request.Weekdays))
+	for i := 0; i < len(data); i++ {
+		data[i].Weekday = request.Weekdays[i].Weekday
+		data[i].WorkingHours = request.Weekdays[i].WorkingHours
+	}
+
+	schedules, err := c.courierService.SetWeeklySchedule(id, data)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toCourierScheduleResponse(id, schedules))
+}
+
+func (c *ScheduleController) PostScheduleOverride(ctx echo.Context) error {
+	if !c.rateLimiters[postScheduleOverride].RegisterCall() {
+		return cerrors.TooManyRequests.New("post schedule override method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	request := new(dto.CreateScheduleOverrideDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse create schedule override request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid create schedule override request")
+	}
+
+	date, err := time.Parse(dateLayout, request.Date)
+	if err != nil {
+		return cerrors.BadRequest.Wrapf(err, "cannot parse override date, got '%s'", request.Date)
+	}
+
+	override, err := c.courierService.CreateScheduleOverride(service_data.NewScheduleOverrideData{
+		CourierId:    id,
+		Date:         date,
+		Kind:         request.Kind,
+		WorkingHours: request.WorkingHours,
+		Comment:      request.Comment,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toScheduleOverrideDto(override))
+}
+
+func (c *ScheduleController) DeleteScheduleOverride(ctx echo.Context) error {
+	if !c.rateLimiters[deleteScheduleOverride].RegisterCall() {
+		return cerrors.TooManyRequests.New("delete schedule override method overloaded")
+	}
+
+	courierId, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+	overrideId, err := parseInt64PathParam(ctx, "override_id")
+	if err != nil {
+		return err
+	}
+
+	if err = c.courierService.DeleteScheduleOverride(courierId, overrideId); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.EmptyResponse{})
+}
+
+// GetCourierCalendar returns the effective working hours of the courier for dates in [from, to).
+func (c *ScheduleController) GetCourierCalendar(ctx echo.Context) error {
+	if !c.rateLimiters[getCourierCalendar].RegisterCall() {
+		return cerrors.TooManyRequests.New("get courier calendar method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+	from, err := parseDateQueryParam(ctx, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQueryParam(ctx, "to")
+	if err != nil {
+		return err
+	}
+	if !to.After(from) || to.After(from.AddDate(0, 0, maxCalendarDays)) {
+		return cerrors.BadRequest.Newf("'to' must be after 'from' and within %d days", maxCalendarDays)
+	}
+
+	days, err := c.courierService.GetCalendar(id, from, to)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.CalendarDayDto, len(days))
+	for i := 0; i < len(days); i++ {
+		response[i].Date = days[i].Date.Format(dateLayout)
+		response[i].WorkingHours = days[i].WorkingHours
+		response[i].Overrides = make([]dto.ScheduleOverrideDto, len(days[i].Overrides))
+		for j, override := range days[i].Overrides {
+			response[i].Overrides[j] = toScheduleOverrideDto(override)
+		}
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toCourierScheduleResponse(courierId int64, schedules []*model.CourierSchedule) dto.CourierScheduleResponse {
+	response := dto.CourierScheduleResponse{
+		CourierId: courierId,
+		Weekdays:  make([]dto.CourierScheduleDto, len(schedules)),
+	}
+	for i := 0; i < len(schedules); i++ {
+		response.Weekdays[i].Weekday = schedules[i].Weekday
+		response.Weekdays[i].WorkingHours = schedules[i].WorkingHours
+	}
+	return response
+}
+
+func toScheduleOverrideDto(override *model.ScheduleOverride) dto.ScheduleOverrideDto {
+	return dto.ScheduleOverrideDto{
+		OverrideId:   override.OverrideId,
+		CourierId:    override.CourierId,
+		Date:         override.Date.Format(dateLayout),
+		Kind:         override.Kind,
+		WorkingHours: override.WorkingHours,
+		Comment:      override.Comment,
+	}
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const (
+	postShiftStart = handlerName(iota)
+	postShiftEnd
+	getShifts
+)
+
+type ShiftController struct {
+	courierService *services.CourierService
+	rateLimiters   map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewShiftController(s *services.CourierService) *ShiftController {
+	return &ShiftController{
+		courierService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			postShiftStart: rate_limiter.NewRateLimiter(),
+			postShiftEnd:   rate_limiter.NewRateLimiter(),
+			getShifts:      rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+func (c *ShiftController) PostShiftStart(ctx echo.Context) error {
+	if !c.rateLimiters[postShiftStart].RegisterCall() {
+		return cerrors.TooManyRequests.New("post shift start method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	shift, err := c.courierService.StartShift(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toShiftDto(shift))
+}
+
+func (c *ShiftController) PostShiftEnd(ctx echo.Context) error {
+	if !c.rateLimiters[postShiftEnd].RegisterCall() {
+		return cerrors.TooManyRequests.New("post shift end method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+
+	shift, err := c.courierService.EndShift(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toShiftDto(shift))
+}
+
+// GetShifts returns shifts of the courier overlapping dates in [from, to).
+func (c *ShiftController) GetShifts(ctx echo.Context) error {
+	if !c.rateLimiters[getShifts].RegisterCall() {
+		return cerrors.TooManyRequests.New("get shifts method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+	from, err := parseDateQueryParam(ctx, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQueryParam(ctx, "to")
+	if err != nil {
+		return err
+	}
+	if !to.After(from) {
+		return cerrors.BadRequest.New("'to' must be after 'from'")
+	}
+
+	shifts, err := c.courierService.GetShifts(id, from, to)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.ShiftDto, len(shifts))
+	for i := 0; i < len(shifts); i++ {
+		response[i] = toShiftDto(shifts[i])
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toShiftDto(shift *model.Shift) dto.ShiftDto {
+	shiftDto := dto.ShiftDto{
+		ShiftId:   shift.ShiftId,
+		CourierId: shift.CourierId,
+		StartedAt: shift.StartedAt.UTC().Format(time.RFC3339),
+	}
+	if shift.EndedAt != nil {
+		endedAt := shift.EndedAt.UTC().Format(time.RFC3339)
+		shiftDto.EndedAt = &endedAt
+	}
+	return shiftDto
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/reports"
+	"Ya.SumSchool23/services"
+	"bytes"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+const (
+	getCourierStatement = handlerName(iota)
+)
+
+type StatementController struct {
+	statementService *services.StatementService
+	rateLimiters     map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewStatementController(s *services.StatementService) *StatementController {
+	return &StatementController{
+		statementService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getCourierStatement: rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+// GetCourierStatement renders the courier statement of [from, to) as CSV (default) or HTML.
+func (c *StatementController) GetCourierStatement(ctx echo.Context) error {
+	if !c.rateLimiters[getCourierStatement].RegisterCall() {
+		return cerrors.TooManyRequests.New("get courier statement method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "courier_id")
+	if err != nil {
+		return err
+	}
+	from, err := parseDateQueryParam(ctx, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQueryParam(ctx, "to")
+	if err != nil {
+		return err
+	}
+	if !to.After(from) || to.After(from.AddDate(0, 0, maxCalendarDays)) {
+		return cerrors.BadRequest.Newf("'to' must be after 'from' and within %d days", maxCalendarDays)
+	}
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = reports.FormatCsv
+	}
+	if format != reports.FormatCsv && format != reports.FormatHtml {
+		return cerrors.BadRequest.Newf("unknown statement format '%s', expected '%s' or '%s'", format, reports.FormatCsv, reports.FormatHtml)
+	}
+
+	statement, err := c.statementService.GetStatement(id, from, to)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	if err = reports.RenderStatement(&buffer, format, statement); err != nil {
+		return err
+	}
+	if format == reports.FormatCsv {
+		ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="statement-%d-%s-%s.csv"`,
+			id, from.Format(dateLayout), to.Format(dateLayout)))
+	}
+	return ctx.Blob(http.StatusOK, reports.ContentType(format), buffer.Bytes())
+}
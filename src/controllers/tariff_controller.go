@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const (
+	getTariffs = handlerName(iota)
+	getCurrentTariff
+	getTariffByVersion
+	postTariff
+)
+
+type TariffController struct {
+	tariffService *services.TariffService
+	rateLimiters  map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewTariffController(s *services.TariffService) *TariffController {
+	return &TariffController{
+		tariffService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getTariffs:         rate_limiter.NewRateLimiter(),
+			getCurrentTariff:   rate_limiter.NewRateLimiter(),
+			getTariffByVersion: rate_limiter.NewRateLimiter(),
+			postTariff:         rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+func (c *TariffController) GetTariffs(ctx echo.Context) error {
+	if !c.rateLimiters[getTariffs].RegisterCall() {
+		return cerrors.TooManyRequests.New("get tariffs method overloaded")
+	}
+
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	tariffs, err := c.tariffService.GetTariffs(limit, offset)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.TariffDto, len(tariffs))
+	for i := 0; i < len(tariffs); i++ {
+		response[i] = toTariffDto(tariffs[i])
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *TariffController) GetCurrentTariff(ctx echo.Context) error {
+	if !c.rateLimiters[getCurrentTariff].RegisterCall() {
+		return cerrors.TooManyRequests.New("get current tariff method overloaded")
+	}
+
+	tariff, err := c.tariffService.GetCurrentTariff()
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toTariffDto(tariff))
+}
+
+func (c *TariffController) GetTariffByVersion(ctx echo.Context) error {
+	if !c.rateLimiters[getTariffByVersion].RegisterCall() {
+		return cerrors.TooManyRequests.New("get tariff by version method overloaded")
+	}
+
+	version, err := parseInt64PathParam(ctx, "version")
+	if err != nil {
+		return err
+	}
+
+	tariff, err := c.tariffService.GetTariffByVersion(version)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toTariffDto(tariff))
+}
+
+// PostTariff creates a new tariff version, orders created without a cost are priced by it from now on.
+func (c *TariffController) PostTariff(ctx echo.Context) error {
+	if !c.rateLimiters[postTariff].RegisterCall() {
+		return cerrors.TooManyRequests.New("post tariff method overloaded")
+	}
+
+	request := new(dto.CreateTariffDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse create tariff request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid create tariff request")
+	}
+
+	data := service_data.NewTariffData{
+		BasePrice:             request.BasePrice,
+		ZonePrices:            make([]model.TariffZonePrice, len(request.ZonePrices)),
+		PerKgPrice:            request.PerKgPrice,
+		NarrowWindowMinutes:   request.NarrowWindowMinutes,
+		NarrowWindowSurcharge: request.NarrowWindowSurcharge,
+		Surges:                make([]model.TariffSurge, len(request.Surges)),
+	}
+	for i, zone := range request.ZonePrices {
+		data.ZonePrices[i] = model.TariffZonePrice(zone)
+	}
+	for i, surge := range request.Surges {
+		data.Surges[i] = model.TariffSurge(surge)
+	}
+
+	tariff, err := c.tariffService.CreateTariff(data)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toTariffDto(tariff))
+}
+
+func toTariffDto(tariff *model.Tariff) dto.TariffDto {
+	response := dto.TariffDto{
+		Version:               tariff.Version,
+		BasePrice:             tariff.BasePrice,
+		ZonePrices:            make([]dto.TariffZonePriceDto, len(tariff.ZonePrices)),
+		PerKgPrice:            tariff.PerKgPrice,
+		NarrowWindowMinutes:   tariff.NarrowWindowMinutes,
+		NarrowWindowSurcharge: tariff.NarrowWindowSurcharge,
+		Surges:                make([]dto.TariffSurgeDto, len(tariff.Surges)),
+		CreatedAt:             tariff.CreatedAt.Format(time.RFC3339),
+	}
+	for i, zone := range tariff.ZonePrices {
+		response.ZonePrices[i] = dto.TariffZonePriceDto(zone)
+	}
+	for i, surge := range tariff.Surges {
+		response.Surges[i] = dto.TariffSurgeDto(surge)
+	}
+	return response
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"Ya.SumSchool23/controllers/dto"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/rate_limiter"
+	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+const (
+	getWebhooks = handlerName(iota)
+	getWebhookById
+	postWebhook
+	putWebhook
+	deleteWebhook
+	getWebhookDeliveries
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+	rateLimiters   map[handlerName]*rate_limiter.RateLimiter
+}
+
+func NewWebhookController(s *services.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: s,
+		rateLimiters: map[handlerName]*rate_limiter.RateLimiter{
+			getWebhooks:          rate_limiter.NewRateLimiter(),
+			getWebhookById:       rate_limiter.NewRateLimiter(),
+			postWebhook:          rate_limiter.NewRateLimiter(),
+			putWebhook:           rate_limiter.NewRateLimiter(),
+			deleteWebhook:        rate_limiter.NewRateLimiter(),
+			getWebhookDeliveries: rate_limiter.NewRateLimiter(),
+		},
+	}
+}
+
+func (c *WebhookController) GetWebhooks(ctx echo.Context) error {
+	if !c.rateLimiters[getWebhooks].RegisterCall() {
+		return cerrors.TooManyRequests.New("get webhooks method overloaded")
+	}
+
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := c.webhookService.GetWebhooks(limit, offset)
+	if err != nil {
+		return err
+	}
+	response := make([]dto.WebhookDto, len(webhooks))
+	for i, webhook := range webhooks {
+		response[i] = toWebhookDto(webhook)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WebhookController) GetWebhookById(ctx echo.Context) error {
+	if !c.rateLimiters[getWebhookById].RegisterCall() {
+		return cerrors.TooManyRequests.New("get webhook by id method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "webhook_id")
+	if err != nil {
+		return err
+	}
+
+	webhook, err := c.webhookService.GetWebhookById(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toWebhookDto(webhook))
+}
+
+// PostWebhook subscribes a URL to event types, the response has the secret deliveries are signed with.
+func (c *WebhookController) PostWebhook(ctx echo.Context) error {
+	if !c.rateLimiters[postWebhook].RegisterCall() {
+		return cerrors.TooManyRequests.New("post webhook method overloaded")
+	}
+
+	request := new(dto.CreateWebhookDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse create webhook request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid create webhook request")
+	}
+
+	webhook, err := c.webhookService.CreateWebhook(service_data.NewWebhookData{
+		Url:        request.Url,
+		EventTypes: request.EventTypes,
+		Secret:     request.Secret,
+		Active:     true,
+	})
+	if err != nil {
+		return err
+	}
+	response := toWebhookDto(webhook)
+	response.Secret = webhook.Secret
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *WebhookController) PutWebhook(ctx echo.Context) error {
+	if !c.rateLimiters[putWebhook].RegisterCall() {
+		return cerrors.TooManyRequests.New("put webhook method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "webhook_id")
+	if err != nil {
+		return err
+	}
+
+	request := new(dto.UpdateWebhookDto)
+	if err := ctx.Bind(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot parse update webhook request")
+	}
+	if err := ctx.Validate(request); err != nil {
+		return cerrors.BadRequest.Wrap(err, "invalid update webhook request")
+	}
+
+	webhook, err := c.webhookService.UpdateWebhook(service_data.NewWebhookData{
+		WebhookId:  id,
+		Url:        request.Url,
+		EventTypes: request.EventTypes,
+		Active:     request.Active,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, toWebhookDto(webhook))
+}
+
+func (c *WebhookController) DeleteWebhook(ctx echo.Context) error {
+	if !c.rateLimiters[deleteWebhook].RegisterCall() {
+		return cerrors.TooManyRequests.New("delete webhook method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "webhook_id")
+	if err != nil {
+		return err
+	}
+
+	if err = c.webhookService.DeleteWebhook(id); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.EmptyResponse{})
+}
+
+// GetWebhookDeliveries returns deliveries of the webhook newest first, optionally of a status: pending, delivered or dead.
+func (c *WebhookController) GetWebhookDeliveries(ctx echo.Context) error {
+	if !c.rateLimiters[getWebhookDeliveries].RegisterCall() {
+		return cerrors.TooManyRequests.New("get webhook deliveries method overloaded")
+	}
+
+	id, err := parseInt64PathParam(ctx, "webhook_id")
+	if err != nil {
+		return err
+	}
+	limit, err := parseInt64QueryParam(ctx, "limit", 1)
+	if err != nil {
+		return err
+	}
+	offset, err := parseInt64QueryParam(ctx, "offset", 0)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := c.webhookService.GetDeliveries(id, ctx.QueryParam("status"), limit, offset)
+	if err != nil {
+		return err
+	}
+	response := make([]dto.WebhookDeliveryDto, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = toWebhookDeliveryDto(delivery)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toWebhookDto(webhook *model.Webhook) dto.WebhookDto {
+	return dto.WebhookDto{
+		WebhookId:  webhook.WebhookId,
+		Url:        webhook.Url,
+		EventTypes: webhook.EventTypes,
+		Active:     webhook.Active,
+		CreatedAt:  webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryDto(delivery *model.WebhookDelivery) dto.WebhookDeliveryDto {
+	response := dto.WebhookDeliveryDto{
+		DeliveryId:     delivery.DeliveryId,
+		WebhookId:      delivery.WebhookId,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Body:           delivery.Body,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+	// only a pending delivery is attempted again
+	if delivery.Status == model.DeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt.Format(time.RFC3339)
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if delivery.DeliveredAt != nil {
+		deliveredAt := delivery.DeliveredAt.Format(time.RFC3339)
+		response.DeliveredAt = &deliveredAt
+	}
+	return response
+}
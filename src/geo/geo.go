@@ -0,0 +1,152 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Point is a WGS 84 position, GeoJSON stores it as [lon, lat].
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// Ring is a closed line, the first point is equal to the last one.
+type Ring []Point
+
+// Polygon is an outer ring followed by optional holes.
+type Polygon []Ring
+
+// Geometry is a GeoJSON Polygon or MultiPolygon.
+type Geometry struct {
+	Polygons []Polygon
+	bounds   Bounds
+}
+
+type Bounds struct {
+	Min Point
+	Max Point
+}
+
+type geoJsonGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParseGeometry parses a GeoJSON Polygon or MultiPolygon geometry object.
+func ParseGeometry(data []byte) (*Geometry, error) {
+	var raw geoJsonGeometry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse GeoJSON geometry: %w", err)
+	}
+
+	var coordinates [][][][2]float64
+	switch raw.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(raw.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("cannot parse polygon coordinates: %w", err)
+		}
+		coordinates = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(raw.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("cannot parse multipolygon coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("geometry type must be Polygon or MultiPolygon, got '%s'", raw.Type)
+	}
+
+	if len(coordinates) == 0 {
+		return nil, fmt.Errorf("geometry has no polygons")
+	}
+
+	geometry := &Geometry{}
+	for _, polygonCoordinates := range coordinates {
+		if len(polygonCoordinates) == 0 {
+			return nil, fmt.Errorf("polygon has no rings")
+		}
+		polygon := make(Polygon, len(polygonCoordinates))
+		for i, ringCoordinates := range polygonCoordinates {
+			if len(ringCoordinates) < 4 {
+				return nil, fmt.Errorf("ring must have at least 4 positions, got %d", len(ringCoordinates))
+			}
+			if ringCoordinates[0] != ringCoordinates[len(ringCoordinates)-1] {
+				return nil, fmt.Errorf("ring must be closed")
+			}
+			ring := make(Ring, len(ringCoordinates))
+			for j, position := range ringCoordinates {
+				ring[j] = Point{Lon: position[0], Lat: position[1]}
+			}
+			polygon[i] = ring
+		}
+		geometry.Polygons = append(geometry.Polygons, polygon)
+	}
+	geometry.bounds = geometry.computeBounds()
+	return geometry, nil
+}
+
+func (g *Geometry) Bounds() Bounds {
+	return g.bounds
+}
+
+// Contains reports whether the point is inside any polygon and outside of its holes.
+func (g *Geometry) Contains(p Point) bool {
+	if !g.bounds.Contains(p) {
+		return false
+	}
+	for _, polygon := range g.Polygons {
+		if !polygon[0].contains(p) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if hole.contains(p) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Geometry) computeBounds() Bounds {
+	first := g.Polygons[0][0][0]
+	bounds := Bounds{Min: first, Max: first}
+	for _, polygon := range g.Polygons {
+		for _, p := range polygon[0] {
+			if p.Lon < bounds.Min.Lon {
+				bounds.Min.Lon = p.Lon
+			}
+			if p.Lat < bounds.Min.Lat {
+				bounds.Min.Lat = p.Lat
+			}
+			if p.Lon > bounds.Max.Lon {
+				bounds.Max.Lon = p.Lon
+			}
+			if p.Lat > bounds.Max.Lat {
+				bounds.Max.Lat = p.Lat
+			}
+		}
+	}
+	return bounds
+}
+
+func (b Bounds) Contains(p Point) bool {
+	return p.Lon >= b.Min.Lon && p.Lon <= b.Max.Lon && p.Lat >= b.Min.Lat && p.Lat <= b.Max.Lat
+}
+
+// contains uses ray casting, points exactly on the border may go either way.
+func (r Ring) contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
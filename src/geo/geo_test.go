@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const square = `{"type": "Polygon", "coordinates": [
+	[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+	[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+]}`
+
+const islands = `{"type": "MultiPolygon", "coordinates": [
+	[[[20, 0], [30, 0], [30, 10], [20, 10], [20, 0]]],
+	[[[5, 5], [5.5, 5], [5.5, 5.5], [5, 5.5], [5, 5]]]
+]}`
+
+func TestGeometryContains(t *testing.T) {
+	geometry, err := ParseGeometry([]byte(square))
+	require.NoError(t, err)
+
+	require.True(t, geometry.Contains(Point{Lon: 1, Lat: 1}))
+	require.False(t, geometry.Contains(Point{Lon: 5, Lat: 5}), "point in a hole")
+	require.False(t, geometry.Contains(Point{Lon: 11, Lat: 5}))
+}
+
+func TestParseGeometryErrors(t *testing.T) {
+	_, err := ParseGeometry([]byte(`{"type": "Point", "coordinates": [1, 2]}`))
+	require.Error(t, err)
+
+	_, err = ParseGeometry([]byte(`{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`))
+	require.Error(t, err, "ring is not closed")
+}
+
+func TestIndexLocate(t *testing.T) {
+	first, err := ParseGeometry([]byte(square))
+	require.NoError(t, err)
+	second, err := ParseGeometry([]byte(islands))
+	require.NoError(t, err)
+
+	index := NewIndex()
+	index.Add(2, second)
+	index.Add(1, first)
+
+	id, ok := index.Locate(Point{Lon: 1, Lat: 1})
+	require.True(t, ok)
+	require.Equal(t, int64(1), id)
+
+	id, ok = index.Locate(Point{Lon: 5.2, Lat: 5.2})
+	require.True(t, ok, "island inside the hole of the first region")
+	require.Equal(t, int64(2), id)
+
+	id, ok = index.Locate(Point{Lon: 25, Lat: 5})
+	require.True(t, ok)
+	require.Equal(t, int64(2), id)
+
+	_, ok = index.Locate(Point{Lon: 15, Lat: 5})
+	require.False(t, ok)
+}
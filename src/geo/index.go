@@ -0,0 +1,51 @@
+package geo
+
+import "sort"
+
+type indexEntry struct {
+	id       int64
+	geometry *Geometry
+}
+
+// Index finds the region containing a point. Entries are sorted by the west edge of
+// their bounding box, so a lookup only runs the polygon test for entries whose box holds the point.
+// It is not safe for concurrent modification.
+type Index struct {
+	entries []indexEntry
+}
+
+func NewIndex() *Index {
+	return &Index{}
+}
+
+func (i *Index) Add(id int64, geometry *Geometry) {
+	i.entries = append(i.entries, indexEntry{id: id, geometry: geometry})
+	sort.SliceStable(i.entries, func(a, b int) bool {
+		return i.entries[a].geometry.bounds.Min.Lon < i.entries[b].geometry.bounds.Min.Lon
+	})
+}
+
+func (i *Index) Len() int {
+	return len(i.entries)
+}
+
+// Locate returns the id of the geometry containing the point.
+// If geometries overlap the smallest id wins.
+func (i *Index) Locate(p Point) (int64, bool) {
+	end := sort.Search(len(i.entries), func(k int) bool {
+		return i.entries[k].geometry.bounds.Min.Lon > p.Lon
+	})
+
+	var result int64
+	found := false
+	for _, entry := range i.entries[:end] {
+		if found && entry.id > result {
+			continue
+		}
+		if entry.geometry.Contains(p) {
+			result = entry.id
+			found = true
+		}
+	}
+	return result, found
+}
@@ -0,0 +1,35 @@
+// Package outbox publishes domain events stored in the outbox table to downstream systems.
+// Events are delivered at least once, consumers deduplicate them by id.
+package outbox
+
+import (
+	"Ya.SumSchool23/services/model"
+	"encoding/json"
+	"time"
+)
+
+// Publisher delivers an event, an error makes the relay publish the event again later.
+type Publisher interface {
+	Publish(event *model.OutboxEvent) error
+}
+
+// Message is the published form of an event.
+type Message struct {
+	Id            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   int64           `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func NewMessage(event *model.OutboxEvent) Message {
+	return Message{
+		Id:            event.Id,
+		Type:          event.Type,
+		AggregateType: event.AggregateType,
+		AggregateId:   event.AggregateId,
+		Payload:       event.Payload,
+		CreatedAt:     event.CreatedAt,
+	}
+}
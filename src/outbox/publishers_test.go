@@ -0,0 +1,83 @@
+package outbox
+
+import (
+	"Ya.SumSchool23/services/model"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvent(id int64) *model.OutboxEvent {
+	return &model.OutboxEvent{
+		Id:            id,
+		Type:          model.EventOrderCreated,
+		AggregateType: model.AggregateOrder,
+		AggregateId:   10 + id,
+		Payload:       []byte(`{"cost":100}`),
+		CreatedAt:     time.Date(2023, 5, 11, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher := NewFilePublisher(path)
+	require.NoError(t, publisher.Publish(testEvent(1)))
+	require.NoError(t, publisher.Publish(testEvent(2)))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var message Message
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &message))
+	require.Equal(t, int64(2), message.Id)
+	require.Equal(t, model.EventOrderCreated, message.Type)
+	require.Equal(t, int64(12), message.AggregateId)
+	require.JSONEq(t, `{"cost":100}`, string(message.Payload))
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var received []Message
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "1", r.Header.Get("X-Event-Id"))
+		require.Equal(t, model.EventOrderCreated, r.Header.Get("X-Event-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var message Message
+		require.NoError(t, json.Unmarshal(body, &message))
+		received = append(received, message)
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher(server.URL, time.Second)
+	require.NoError(t, publisher.Publish(testEvent(1)))
+	require.Len(t, received, 1)
+	require.Equal(t, int64(11), received[0].AggregateId)
+
+	fail = true
+	require.Error(t, publisher.Publish(testEvent(1)))
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	require.NoError(t, publisher.Publish(testEvent(1)))
+	require.NoError(t, publisher.Publish(testEvent(2)))
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 2)
+	require.Equal(t, int64(1), messages[0].Id)
+	require.Equal(t, int64(2), messages[1].Id)
+}
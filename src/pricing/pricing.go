@@ -0,0 +1,48 @@
+package pricing
+
+import "fmt"
+
+// DefaultNextOrderDiscount is the discount in percent of the second and later orders of a group order.
+const DefaultNextOrderDiscount = 20
+
+// Rule is the pricing of group orders delivered by a courier type.
+type Rule struct {
+	// NextOrderDiscount is the discount in percent of every order of a group after the first one.
+	NextOrderDiscount int64
+}
+
+// Rules holds a rule per courier type, orders of courier types without a rule are paid in full.
+type Rules map[string]Rule
+
+func (r Rules) Validate() error {
+	for courierType, rule := range r {
+		if rule.NextOrderDiscount < 0 || rule.NextOrderDiscount > 100 {
+			return fmt.Errorf("discount of %s group orders must be within [0, 100], got %d", courierType, rule.NextOrderDiscount)
+		}
+	}
+	return nil
+}
+
+// GroupCosts returns the effective cost of every order of a group in delivery order:
+// the first order at full price and the others with the discount of the courier type.
+func (r Rules) GroupCosts(courierType string, costs []int64) []int64 {
+	result := make([]int64, len(costs))
+	discount := r[courierType].NextOrderDiscount
+	for i, cost := range costs {
+		if i == 0 {
+			result[i] = cost
+		} else {
+			result[i] = cost * (100 - discount) / 100
+		}
+	}
+	return result
+}
+
+// GroupCost is the total effective cost of a group.
+func (r Rules) GroupCost(courierType string, costs []int64) int64 {
+	var total int64
+	for _, cost := range r.GroupCosts(courierType, costs) {
+		total += cost
+	}
+	return total
+}
@@ -0,0 +1,47 @@
+package pricing
+
+import (
+	"Ya.SumSchool23/time_interval"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGroupCosts(t *testing.T) {
+	rules := Rules{"FOOT": {NextOrderDiscount: 20}, "AUTO": {NextOrderDiscount: 50}}
+
+	require.Equal(t, []int64{100, 80, 160}, rules.GroupCosts("FOOT", []int64{100, 100, 200}))
+	require.Equal(t, []int64{100, 50}, rules.GroupCosts("AUTO", []int64{100, 100}))
+	require.Equal(t, []int64{100, 100}, rules.GroupCosts("BIKE", []int64{100, 100}))
+	require.Equal(t, int64(340), rules.GroupCost("FOOT", []int64{100, 100, 200}))
+	require.Empty(t, rules.GroupCosts("FOOT", nil))
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Rules{"FOOT": {NextOrderDiscount: 0}, "BIKE": {NextOrderDiscount: 100}}.Validate())
+	require.Error(t, Rules{"FOOT": {NextOrderDiscount: 101}}.Validate())
+	require.Error(t, Rules{"FOOT": {NextOrderDiscount: -1}}.Validate())
+}
+
+func TestTariffPrice(t *testing.T) {
+	morning, err := time_interval.Parse("08:00-10:00")
+	require.NoError(t, err)
+	tariff := Tariff{
+		BasePrice:             100,
+		ZonePrices:            map[int64]int64{2: 150},
+		PerKgPrice:            10,
+		NarrowWindowMinutes:   60,
+		NarrowWindowSurcharge: 50,
+		Surges:                []Surge{{Interval: morning, Multiplier: 1.5}},
+	}
+	windows := func(strs ...string) []time_interval.Interval {
+		intervals, err := time_interval.ParseAll(strs)
+		require.NoError(t, err)
+		return intervals
+	}
+
+	require.Equal(t, int64(125), tariff.Price(2.5, 1, windows("12:00-14:00")))
+	require.Equal(t, int64(170), tariff.Price(2, 2, windows("12:00-14:00")))
+	require.Equal(t, int64(170), tariff.Price(2, 1, windows("12:00-12:30")))
+	require.Equal(t, int64(255), tariff.Price(2, 1, windows("12:00-12:20", "09:00-09:20")), "(120 + 50) * 1.5")
+	require.Equal(t, int64(120), tariff.Price(2, 1, nil))
+}
@@ -0,0 +1,49 @@
+package pricing
+
+import (
+	"Ya.SumSchool23/time_interval"
+	"math"
+)
+
+// Tariff prices an order from its weight, region and delivery hours.
+type Tariff struct {
+	// BasePrice is used for regions without a zone price.
+	BasePrice  int64
+	ZonePrices map[int64]int64
+	PerKgPrice int64
+	// NarrowWindowSurcharge is added when the delivery hours last less than NarrowWindowMinutes in total.
+	NarrowWindowMinutes   int
+	NarrowWindowSurcharge int64
+	Surges                []Surge
+}
+
+// Surge multiplies the price of orders whose earliest delivery window starts within the interval.
+type Surge struct {
+	Interval   time_interval.Interval
+	Multiplier float64
+}
+
+// Price is (base price of the zone + weight * per-kg price + narrow window surcharge) * surge multiplier,
+// rounded to the nearest integer. The first surge containing the start of the earliest window applies.
+func (t Tariff) Price(weight float64, region int64, windows []time_interval.Interval) int64 {
+	base, ok := t.ZonePrices[region]
+	if !ok {
+		base = t.BasePrice
+	}
+	price := float64(base) + weight*float64(t.PerKgPrice)
+	if len(windows) == 0 {
+		return int64(math.Round(price))
+	}
+
+	if time_interval.TotalMinutes(windows) < t.NarrowWindowMinutes {
+		price += float64(t.NarrowWindowSurcharge)
+	}
+	start := time_interval.Merge(windows)[0].Start
+	for _, surge := range t.Surges {
+		if start >= surge.Interval.Start && start < surge.Interval.End {
+			price *= surge.Multiplier
+			break
+		}
+	}
+	return int64(math.Round(price))
+}
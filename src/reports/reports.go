@@ -0,0 +1,72 @@
+package reports
+
+import (
+	"Ya.SumSchool23/services/model"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	FormatCsv  = "csv"
+	FormatHtml = "html"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var funcs = map[string]interface{}{
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+	"csv":   csvField,
+	"deref": deref,
+}
+
+var (
+	csvStatement  = texttemplate.Must(texttemplate.New("statement.csv.tmpl").Funcs(funcs).ParseFS(templates, "templates/statement.csv.tmpl"))
+	htmlStatement = htmltemplate.Must(htmltemplate.New("statement.html.tmpl").Funcs(funcs).ParseFS(templates, "templates/statement.html.tmpl"))
+)
+
+// ContentType of a statement format.
+func ContentType(format string) string {
+	if format == FormatHtml {
+		return "text/html; charset=UTF-8"
+	}
+	return "text/csv; charset=UTF-8"
+}
+
+func RenderStatement(w io.Writer, format string, statement *model.Statement) error {
+	switch format {
+	case FormatCsv:
+		return csvStatement.Execute(w, statement)
+	case FormatHtml:
+		return htmlStatement.Execute(w, statement)
+	}
+	return fmt.Errorf("unknown statement format '%s', expected '%s' or '%s'", format, FormatCsv, FormatHtml)
+}
+
+// csvField quotes the value if it has a separator, a quote or a line break.
+func csvField(value interface{}) string {
+	str := fmt.Sprint(value)
+	if strings.ContainsAny(str, ",\"\r\n") {
+		return `"` + strings.ReplaceAll(str, `"`, `""`) + `"`
+	}
+	return str
+}
+
+func deref(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case *int64:
+		if v != nil {
+			return *v
+		}
+	}
+	return ""
+}
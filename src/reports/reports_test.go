@@ -0,0 +1,57 @@
+package reports
+
+import (
+	"Ya.SumSchool23/services/model"
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testStatement() *model.Statement {
+	completed := "2023-05-11T10:00:00Z"
+	comment := `late, "again"`
+	orderId := int64(7)
+	rating := int64(3)
+	return &model.Statement{
+		Courier: &model.Courier{CourierId: 1, CourierType: model.CourierTypeFoot},
+		From:    time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		Orders: []model.StatementOrder{
+			{Order: &model.Order{OrderId: 7, Cost: 100, CompletedTime: &completed}, EffectiveCost: 80, Discount: 20, Earning: 160},
+		},
+		Adjustments: []*model.LedgerEntry{
+			{EntryId: 3, Kind: model.LedgerKindPenalty, Reason: model.PenaltyLateDelivery, Amount: -50, OrderId: &orderId,
+				Comment: &comment, Date: time.Date(2023, 5, 11, 0, 0, 0, 0, time.UTC)},
+		},
+		Rating:    &rating,
+		Earnings:  160,
+		Penalties: -50,
+		Total:     110,
+	}
+}
+
+func TestRenderCsvStatement(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, RenderStatement(&buffer, FormatCsv, testStatement()))
+
+	csv := buffer.String()
+	require.Contains(t, csv, "1,FOOT,2023-05-01,2023-06-01\n")
+	require.Contains(t, csv, "7,2023-05-11T10:00:00Z,100,20,80,160\n")
+	require.Contains(t, csv, `3,2023-05-11,penalty,late_delivery,7,-50,"late, ""again"""`+"\n")
+	require.Contains(t, csv, "160,0,-50,110,0,3\n")
+}
+
+func TestRenderHtmlStatement(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, RenderStatement(&buffer, FormatHtml, testStatement()))
+
+	html := buffer.String()
+	require.Contains(t, html, "Statement of courier 1 (FOOT)")
+	require.Contains(t, html, "late, &#34;again&#34;")
+	require.Contains(t, html, `<td class="number">160</td>`)
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	require.Error(t, RenderStatement(&bytes.Buffer{}, "pdf", testStatement()))
+}
@@ -0,0 +1,399 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"sort"
+	"time"
+)
+
+type AssignmentRepository struct {
+	db *sql.DB
+}
+
+func NewAssignmentRepository(db *sql.DB) *AssignmentRepository {
+	return &AssignmentRepository{
+		db: db,
+	}
+}
+
+// GetGroupOrders returns group orders of the date with their orders, optionally only of one courier.
+func (r *AssignmentRepository) GetGroupOrders(date time.Time, courierId *int64) ([]*model.GroupOrder, error) {
+	return r.queryGroupOrders("assignment_date = $1 AND ($2::integer IS NULL OR courier_id = $2)", date, courierId)
+}
+
+func (r *AssignmentRepository) GetGroupOrderById(id int64) (*model.GroupOrder, error) {
+	groups, err := r.queryGroupOrders("id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, cerrors.NotFound.Newf("group order with id = '%v' not found", id)
+	}
+	return groups[0], nil
+}
+
+func (r *AssignmentRepository) queryGroupOrders(where string, args ...interface{}) ([]*model.GroupOrder, error) {
+
+	rows, err := r.db.Query(
+		`SELECT id, courier_id, assignment_date, start_minute, finish_minute, strategy FROM group_orders
+		WHERE `+where+` ORDER BY courier_id, start_minute, id`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*model.GroupOrder
+	byId := make(map[int64]*model.GroupOrder)
+	for rows.Next() {
+		group := &model.GroupOrder{}
+		if err = rows.Scan(&group.GroupOrderId, &group.CourierId, &group.Date, &group.StartMinute,
+			&group.FinishMinute, &group.Strategy); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+		byId[group.GroupOrderId] = group
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	ids := make([]int64, len(groups))
+	for i, group := range groups {
+		ids[i] = group.GroupOrderId
+	}
+	orderRows, err := r.db.Query(
+		"SELECT "+orderColumns+" FROM orders WHERE group_order_id = ANY($1) ORDER BY planned_arrival, order_id",
+		pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer orderRows.Close()
+
+	orders, err := scanOrders(orderRows)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		group := byId[*order.GroupOrderId]
+		group.Orders = append(group.Orders, order)
+	}
+	return groups, nil
+}
+
+// SavePlan applies the plan in one transaction.
+func (r *AssignmentRepository) SavePlan(plan service_data.NewAssignmentPlanData) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = savePlan(tx, plan); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveManualAssignment applies the plan of a manual assignment and records the override.
+// The group order id of the override is taken from the order after the plan is applied.
+func (r *AssignmentRepository) SaveManualAssignment(plan service_data.NewAssignmentPlanData, override *model.AssignmentOverride) (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err = savePlan(tx, plan); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	row := tx.QueryRow(
+		`INSERT INTO assignment_overrides(order_id, courier_id, previous_courier_id, group_order_id, assignment_date, forced)
+		SELECT order_id, $2, $3, group_order_id, $4, $5 FROM orders WHERE order_id = $1
+		RETURNING id`,
+		override.OrderId, override.CourierId, override.PreviousCourierId, plan.Date, override.Forced)
+	if err = row.Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *AssignmentRepository) GetOverrideById(id int64) (*model.AssignmentOverride, error) {
+
+	row := r.db.QueryRow(
+		`SELECT id, order_id, courier_id, previous_courier_id, group_order_id, assignment_date, forced, created_at
+		FROM assignment_overrides WHERE id = $1`,
+		id)
+
+	override := &model.AssignmentOverride{}
+	if err := row.Scan(&override.OverrideId, &override.OrderId, &override.CourierId, &override.PreviousCourierId,
+		&override.GroupOrderId, &override.Date, &override.Forced, &override.CreatedAt); err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "assignment override with id = '%v' not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// savePlan releases orders and groups, stores the planned groups (groups with an id are updated,
+// the others are created for the date), pins orders and replaces rejections.
+// Rejections of planned orders are removed.
+func savePlan(tx *sql.Tx, plan service_data.NewAssignmentPlanData) error {
+	orderIds := append([]int64{}, plan.ReleasedOrderIds...)
+	for _, group := range plan.Groups {
+		for _, stop := range group.Stops {
+			orderIds = append(orderIds, stop.OrderId)
+		}
+	}
+	previous, err := orderCouriers(tx, orderIds, plan.ReleasedGroupIds)
+	if err != nil {
+		return err
+	}
+	planned := make(map[int64]bool)
+
+	if len(plan.ReleasedOrderIds) > 0 {
+		_, err := tx.Exec(
+			"UPDATE orders SET group_order_id = NULL, planned_arrival = NULL, pinned = false WHERE order_id = ANY($1)",
+			pq.Array(plan.ReleasedOrderIds))
+		if err != nil {
+			return err
+		}
+	}
+	if err := releaseGroups(tx, plan.ReleasedGroupIds); err != nil {
+		return err
+	}
+
+	for _, group := range plan.Groups {
+		id := group.GroupOrderId
+		if id == 0 {
+			row := tx.QueryRow(
+				`INSERT INTO group_orders(courier_id, assignment_date, start_minute, finish_minute, strategy)
+				VALUES ($1,$2,$3,$4,$5) RETURNING id`,
+				group.CourierId, plan.Date, group.StartMinute, group.FinishMinute, plan.Strategy)
+			if err := row.Scan(&id); err != nil {
+				return err
+			}
+		} else {
+			_, err := tx.Exec("UPDATE group_orders SET start_minute = $1, finish_minute = $2 WHERE id = $3",
+				group.StartMinute, group.FinishMinute, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, stop := range group.Stops {
+			_, err := tx.Exec("UPDATE orders SET group_order_id = $1, planned_arrival = $2 WHERE order_id = $3",
+				id, stop.PlannedArrival, stop.OrderId)
+			if err != nil {
+				return err
+			}
+			if _, err = tx.Exec("DELETE FROM assignment_rejections WHERE order_id = $1", stop.OrderId); err != nil {
+				return err
+			}
+
+			planned[stop.OrderId] = true
+			payload := map[string]interface{}{
+				"courier_id":      group.CourierId,
+				"group_order_id":  id,
+				"assignment_date": plan.Date.Format("2006-01-02"),
+				"planned_arrival": stop.PlannedArrival,
+				"strategy":        plan.Strategy,
+			}
+			from, ok := previous[stop.OrderId]
+			if ok && from == group.CourierId {
+				continue
+			}
+			eventType := model.OrderEventAssigned
+			if ok {
+				eventType = model.OrderEventReassigned
+				payload["previous_courier_id"] = from
+			}
+			if err = insertOrderEvent(tx, stop.OrderId, eventType, plan.Actor, payload); err != nil {
+				return err
+			}
+			payload["order_id"] = stop.OrderId
+			if err = insertOutboxEvent(tx, model.EventOrderAssigned, model.AggregateOrder, stop.OrderId, payload); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = unassignedEvents(tx, previous, planned, plan.Actor); err != nil {
+		return err
+	}
+
+	if len(plan.PinnedOrderIds) > 0 {
+		_, err := tx.Exec("UPDATE orders SET pinned = true WHERE order_id = ANY($1)", pq.Array(plan.PinnedOrderIds))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, rejection := range plan.Rejections {
+		misses := make([]nearestMissRow, len(rejection.NearestMisses))
+		for i, miss := range rejection.NearestMisses {
+			misses[i] = nearestMissRow(miss)
+		}
+		missesJson, err := json.Marshal(misses)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO assignment_rejections(order_id, assignment_date, strategy, reason, nearest_misses)
+			VALUES ($1,$2,$3,$4,$5)
+			ON CONFLICT (order_id) DO UPDATE SET assignment_date = excluded.assignment_date, strategy = excluded.strategy,
+			reason = excluded.reason, nearest_misses = excluded.nearest_misses, created_at = now()`,
+			rejection.OrderId, plan.Date, plan.Strategy, rejection.Reason, missesJson)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRejection returns why the order was left unassigned by the last assignment run, nil if it was not.
+func (r *AssignmentRepository) GetRejection(orderId int64) (*model.AssignmentRejection, error) {
+
+	row := r.db.QueryRow(
+		`SELECT order_id, assignment_date, strategy, reason, nearest_misses, created_at FROM assignment_rejections
+		WHERE order_id = $1`,
+		orderId)
+
+	rejection := &model.AssignmentRejection{}
+	var missesJson []byte
+	if err := row.Scan(&rejection.OrderId, &rejection.Date, &rejection.Strategy, &rejection.Reason,
+		&missesJson, &rejection.CreatedAt); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var misses []nearestMissRow
+	if err := json.Unmarshal(missesJson, &misses); err != nil {
+		return nil, err
+	}
+	rejection.NearestMisses = make([]model.NearestMiss, len(misses))
+	for i, miss := range misses {
+		rejection.NearestMisses[i] = model.NearestMiss(miss)
+	}
+	return rejection, nil
+}
+
+// unassignedEvents records orders that had a courier and are not planned again in the order history
+// and the outbox, in order id order.
+func unassignedEvents(tx *sql.Tx, previous map[int64]int64, planned map[int64]bool, actor string) error {
+	released := make([]int64, 0, len(previous))
+	for orderId := range previous {
+		if !planned[orderId] {
+			released = append(released, orderId)
+		}
+	}
+	sort.Slice(released, func(a, b int) bool {
+		return released[a] < released[b]
+	})
+	for _, orderId := range released {
+		payload := map[string]interface{}{"previous_courier_id": previous[orderId]}
+		if err := insertOrderEvent(tx, orderId, model.OrderEventUnassigned, actor, payload); err != nil {
+			return err
+		}
+		// the event concerns the courier the order is taken from
+		payload = map[string]interface{}{"order_id": orderId, "courier_id": previous[orderId]}
+		if err := insertOutboxEvent(tx, model.EventOrderUnassigned, model.AggregateOrder, orderId, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type nearestMissRow struct {
+	CourierId int64  `json:"courier_id"`
+	Reason    string `json:"reason"`
+}
+
+// DeactivateCourier deactivates or archives the courier and returns the orders of the courier's group orders
+// dated from the date on to the pool. Completed orders stay in their group order, a group order is deleted once
+// it has none left. The orders are unassigned by the actor.
+func (r *AssignmentRepository) DeactivateCourier(courierId int64, archive bool, from time.Time, actor string) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = updateCourierStatus(tx, courierId, false, archive); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(
+		`SELECT o.order_id, o.group_order_id FROM orders o JOIN group_orders g ON g.id = o.group_order_id
+		WHERE g.courier_id = $1 AND g.assignment_date >= $2 AND o.completed_time IS NULL`,
+		courierId, from)
+	if err != nil {
+		return err
+	}
+	var orderIds, groupIds []int64
+	for rows.Next() {
+		var orderId, groupId int64
+		if err = rows.Scan(&orderId, &groupId); err != nil {
+			rows.Close()
+			return err
+		}
+		orderIds = append(orderIds, orderId)
+		groupIds = append(groupIds, groupId)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(orderIds) == 0 {
+		return tx.Commit()
+	}
+
+	previous, err := orderCouriers(tx, orderIds, nil)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE orders SET group_order_id = NULL, planned_arrival = NULL, pinned = false WHERE order_id = ANY($1)",
+		pq.Array(orderIds))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"DELETE FROM group_orders g WHERE id = ANY($1) AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.group_order_id = g.id)",
+		pq.Array(groupIds))
+	if err != nil {
+		return err
+	}
+	if err = unassignedEvents(tx, previous, nil, actor); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func releaseGroups(tx *sql.Tx, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(
+		"UPDATE orders SET group_order_id = NULL, planned_arrival = NULL, pinned = false WHERE group_order_id = ANY($1)",
+		pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM group_orders WHERE id = ANY($1)", pq.Array(ids))
+	return err
+}
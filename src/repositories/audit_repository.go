@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"time"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+const auditColumns = "id, actor, method, route, entity_ids, entity_type, entity_id, before, after, diff, request_id, status, created_at"
+
+// CreateEntries stores the entries of a request in one transaction.
+func (r *AuditRepository) CreateEntries(entries []*model.AuditEntry) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		entityIds, err := json.Marshal(entry.EntityIds)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO audit_log(actor, method, route, entity_ids, entity_type, entity_id, before, after, diff, request_id, status)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
+			entry.Actor, entry.Method, entry.Route, entityIds, entry.EntityType, entry.EntityId,
+			nullJson(entry.Before), nullJson(entry.After), nullJson(entry.Diff), entry.RequestId, entry.Status)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEntries returns the entries matching the filter, newest first. An entity also matches
+// calls that had its id as a path param, like order_id for the order entity.
+func (r *AuditRepository) GetEntries(filter service_data.AuditFilterData) ([]*model.AuditEntry, error) {
+	rows, err := r.db.Query(
+		"SELECT "+auditColumns+` FROM audit_log
+		WHERE ($1 = '' OR actor = $1)
+		AND ($2 = '' OR entity_type = $2 OR entity_ids ? ($2 || '_id'))
+		AND ($3::bigint IS NULL OR (entity_type = $2 AND entity_id = $3) OR entity_ids ->> ($2 || '_id') = $3::text)
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at < $5)
+		ORDER BY id DESC LIMIT $6 OFFSET $7`,
+		filter.Actor, filter.EntityType, filter.EntityId, filter.From, filter.To, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuditEntries(rows)
+}
+
+// GetEntriesBefore returns up to limit of the oldest entries created before the time, oldest first.
+func (r *AuditRepository) GetEntriesBefore(before time.Time, limit int64) ([]*model.AuditEntry, error) {
+	rows, err := r.db.Query("SELECT "+auditColumns+" FROM audit_log WHERE created_at < $1 ORDER BY id LIMIT $2", before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuditEntries(rows)
+}
+
+// DeleteEntries removes exported entries.
+func (r *AuditRepository) DeleteEntries(ids []int64) error {
+	_, err := r.db.Exec("DELETE FROM audit_log WHERE id = ANY($1)", pq.Array(ids))
+	return err
+}
+
+// nullJson stores an empty snapshot as NULL.
+func nullJson(value []byte) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return value
+}
+
+func scanAuditEntries(rows *sql.Rows) ([]*model.AuditEntry, error) {
+	entries := make([]*model.AuditEntry, 0)
+	for rows.Next() {
+		entry := &model.AuditEntry{}
+		var entityIds []byte
+		err := rows.Scan(&entry.Id, &entry.Actor, &entry.Method, &entry.Route, &entityIds, &entry.EntityType, &entry.EntityId,
+			&entry.Before, &entry.After, &entry.Diff, &entry.RequestId, &entry.Status, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(entityIds, &entry.EntityIds); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
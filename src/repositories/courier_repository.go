@@ -21,28 +21,36 @@ func NewCourierRepository(db *sql.DB) *CourierRepository {
 func (r *CourierRepository) GetCouriers(limit, offset int64) ([]*model.Courier, error) {
 
 	rows, err := r.db.Query(
-		"SELECT id, courier_type, regions, working_hours FROM couriers ORDER BY id LIMIT $1 OFFSET $2",
+		"SELECT id, courier_type, regions, working_hours, active, archived FROM couriers ORDER BY id LIMIT $1 OFFSET $2",
 		limit, offset)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	return scanCouriers(rows)
+}
+
+// GetAvailableCouriers returns active couriers that are not archived.
+// If region is not nil only couriers working in that region are returned.
+func (r *CourierRepository) GetAvailableCouriers(region *int64) ([]*model.Courier, error) {
+
+	rows, err := r.db.Query(
+		`SELECT id, courier_type, regions, working_hours, active, archived FROM couriers
+		WHERE active AND NOT archived AND ($1::integer IS NULL OR $1 = ANY(regions))
+		ORDER BY id`,
+		region)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var couriers []*model.Courier
-	for rows.Next() {
-		courier := &model.Courier{}
-		if err = rows.Scan(&courier.CourierId, &courier.CourierType, pq.Array(&courier.Regions), pq.Array(&courier.WorkingHours)); err != nil {
-			return nil, err
-		}
-		couriers = append(couriers, courier)
-	}
-	return couriers, nil
+	return scanCouriers(rows)
 }
 
 func (r *CourierRepository) GetCourierById(id int64) (*model.Courier, error) {
 
-	row := r.db.QueryRow("SELECT id, courier_type, regions, working_hours FROM couriers WHERE id = $1", id)
+	row := r.db.QueryRow("SELECT id, courier_type, regions, working_hours, active, archived FROM couriers WHERE id = $1", id)
 
 	courier := &model.Courier{}
 	if err := row.Scan(
@@ -50,6 +58,8 @@ func (r *CourierRepository) GetCourierById(id int64) (*model.Courier, error) {
 		&courier.CourierType,
 		pq.Array(&courier.Regions),
 		pq.Array(&courier.WorkingHours),
+		&courier.Active,
+		&courier.Archived,
 	); err == sql.ErrNoRows {
 		return nil, cerrors.NotFound.Wrapf(err, "courier with id = '%v' not found", id)
 	} else if err != nil {
@@ -58,16 +68,80 @@ func (r *CourierRepository) GetCourierById(id int64) (*model.Courier, error) {
 	return courier, nil
 }
 
+// CreateCouriers inserts the couriers and their CourierCreated events in one transaction.
 func (r *CourierRepository) CreateCouriers(data []service_data.NewCourierData) ([]int64, error) {
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	ids := make([]int64, len(data))
 
 	for i := 0; i < len(data); i++ {
-		row := r.db.QueryRow("INSERT INTO couriers(courier_type, regions, working_hours) VALUES ($1,$2,$3) RETURNING id",
+		row := tx.QueryRow("INSERT INTO couriers(courier_type, regions, working_hours) VALUES ($1,$2,$3) RETURNING id",
 			data[i].CourierType, pq.Array(data[i].Regions), pq.StringArray(data[i].WorkingHours))
 		if err := row.Scan(&ids[i]); err != nil {
 			return nil, err
 		}
+		payload := map[string]interface{}{
+			"courier_id":    ids[i],
+			"courier_type":  data[i].CourierType,
+			"regions":       data[i].Regions,
+			"working_hours": data[i].WorkingHours,
+		}
+		if err := insertOutboxEvent(tx, model.EventCourierCreated, model.AggregateCourier, ids[i], payload); err != nil {
+			return nil, err
+		}
+	}
+	return ids, tx.Commit()
+}
+
+// UpdateCourierStatus updates the courier and stores its CourierUpdated event in one transaction.
+func (r *CourierRepository) UpdateCourierStatus(id int64, active, archived bool) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = updateCourierStatus(tx, id, active, archived); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func updateCourierStatus(tx *sql.Tx, id int64, active, archived bool) error {
+	res, err := tx.Exec("UPDATE couriers SET active = $1, archived = $2 WHERE id = $3", active, archived, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return cerrors.NotFound.Newf("courier with id = '%v' not found", id)
+	}
+	payload := map[string]interface{}{
+		"courier_id": id,
+		"active":     active,
+		"archived":   archived,
+	}
+	return insertOutboxEvent(tx, model.EventCourierUpdated, model.AggregateCourier, id, payload)
+}
+
+func scanCouriers(rows *sql.Rows) ([]*model.Courier, error) {
+	var couriers []*model.Courier
+	for rows.Next() {
+		courier := &model.Courier{}
+		if err := rows.Scan(&courier.CourierId, &courier.CourierType, pq.Array(&courier.Regions),
+			pq.Array(&courier.WorkingHours), &courier.Active, &courier.Archived); err != nil {
+			return nil, err
+		}
+		couriers = append(couriers, courier)
 	}
-	return ids, nil
+	return couriers, rows.Err()
 }
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"github.com/lib/pq"
+	"time"
+)
+
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{
+		db: db,
+	}
+}
+
+const ledgerColumns = "id, courier_id, kind, reason, amount, order_id, comment, entry_date, period_id, created_at"
+
+// CreateEntry stores the entry unless its date is in a closed payout period.
+func (r *LedgerRepository) CreateEntry(data service_data.NewLedgerEntryData) (int64, error) {
+
+	var id int64
+	row := r.db.QueryRow(
+		`INSERT INTO courier_ledger(courier_id, kind, reason, amount, order_id, comment, entry_date)
+		SELECT $1,$2,$3,$4,$5,$6,$7
+		WHERE NOT EXISTS (SELECT 1 FROM payout_periods WHERE period_from <= $7 AND period_to > $7)
+		RETURNING id`,
+		data.CourierId, data.Kind, data.Reason, data.Amount, data.OrderId, data.Comment, data.Date)
+	err := row.Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, cerrors.BadRequest.Newf("payout period of '%s' is closed", data.Date.Format("2006-01-02"))
+	}
+	// only entries of an order can violate these constraints
+	if pqErr, ok := err.(*pq.Error); ok && data.OrderId != nil {
+		switch {
+		case pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == "courier_ledger_order_idx":
+			return 0, cerrors.BadRequest.Wrapf(err, "order with id = '%v' already has a %s %s entry", *data.OrderId, data.Kind, data.Reason)
+		case pqErr.Code.Name() == "foreign_key_violation" && pqErr.Constraint == "courier_ledger_order_id_fkey":
+			return 0, cerrors.NotFound.Wrapf(err, "order with id = '%v' not found", *data.OrderId)
+		}
+	}
+	return id, err
+}
+
+func (r *LedgerRepository) GetEntryById(id int64) (*model.LedgerEntry, error) {
+	entries, err := r.queryEntries("WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, cerrors.NotFound.Newf("ledger entry with id = '%v' not found", id)
+	}
+	return entries[0], nil
+}
+
+// GetEntries returns a page of the courier entries, the latest first.
+func (r *LedgerRepository) GetEntries(courierId, limit, offset int64) ([]*model.LedgerEntry, error) {
+	return r.queryEntries("WHERE courier_id = $1 ORDER BY entry_date DESC, id DESC LIMIT $2 OFFSET $3",
+		courierId, limit, offset)
+}
+
+// GetAdjustments returns the courier bonuses, penalties and payouts dated in [from, to).
+func (r *LedgerRepository) GetAdjustments(courierId int64, from, to time.Time) ([]*model.LedgerEntry, error) {
+	return r.queryEntries("WHERE courier_id = $1 AND kind <> $2 AND entry_date >= $3 AND entry_date < $4 ORDER BY entry_date, id",
+		courierId, model.LedgerKindEarning, from, to)
+}
+
+// GetEarnings returns the courier earnings dated in [from, to).
+func (r *LedgerRepository) GetEarnings(courierId int64, from, to time.Time) ([]*model.LedgerEntry, error) {
+	return r.queryEntries("WHERE courier_id = $1 AND kind = $2 AND entry_date >= $3 AND entry_date < $4 ORDER BY entry_date, id",
+		courierId, model.LedgerKindEarning, from, to)
+}
+
+// SumEarnings returns the sum of earnings dated in [from, to) by courier id, couriers without earnings are missing.
+func (r *LedgerRepository) SumEarnings(courierIds []int64, from, to time.Time) (map[int64]int64, error) {
+	rows, err := r.db.Query(
+		`SELECT courier_id, sum(amount) FROM courier_ledger
+		WHERE courier_id = ANY($1) AND kind = $2 AND entry_date >= $3 AND entry_date < $4 GROUP BY courier_id`,
+		pq.Array(courierIds), model.LedgerKindEarning, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]int64, len(courierIds))
+	for rows.Next() {
+		var courierId, earnings int64
+		if err = rows.Scan(&courierId, &earnings); err != nil {
+			return nil, err
+		}
+		result[courierId] = earnings
+	}
+	return result, rows.Err()
+}
+
+// GetBalance is the sum of all entries of the courier: earned and not paid out yet.
+func (r *LedgerRepository) GetBalance(courierId int64) (int64, error) {
+	var balance int64
+	row := r.db.QueryRow("SELECT coalesce(sum(amount), 0) FROM courier_ledger WHERE courier_id = $1", courierId)
+	return balance, row.Scan(&balance)
+}
+
+// ClosePeriod pays out the balance every courier earned by open entries dated in [from, to) with a payout entry
+// dated on the last day of the period and freezes the entries. Periods cannot overlap.
+func (r *LedgerRepository) ClosePeriod(from, to time.Time) (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// entries cannot be added to the period while it is being closed
+	if _, err = tx.Exec("LOCK TABLE payout_periods, courier_ledger IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return 0, err
+	}
+
+	var overlapping bool
+	row := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM payout_periods WHERE period_from < $2 AND period_to > $1)", from, to)
+	if err = row.Scan(&overlapping); err != nil {
+		return 0, err
+	}
+	if overlapping {
+		return 0, cerrors.BadRequest.Newf("period from '%s' to '%s' overlaps a closed period",
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	var id int64
+	row = tx.QueryRow("INSERT INTO payout_periods(period_from, period_to) VALUES ($1,$2) RETURNING id", from, to)
+	if err = row.Scan(&id); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO courier_ledger(courier_id, kind, amount, entry_date, period_id)
+		SELECT courier_id, $3, -sum(amount), $2::date - 1, $4 FROM courier_ledger
+		WHERE period_id IS NULL AND entry_date >= $1 AND entry_date < $2
+		GROUP BY courier_id HAVING sum(amount) <> 0`,
+		from, to, model.LedgerKindPayout, id)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec("UPDATE courier_ledger SET period_id = $3 WHERE period_id IS NULL AND entry_date >= $1 AND entry_date < $2",
+		from, to, id)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *LedgerRepository) GetPeriodById(id int64) (*model.PayoutPeriod, error) {
+
+	row := r.db.QueryRow("SELECT id, period_from, period_to, closed_at FROM payout_periods WHERE id = $1", id)
+	period := &model.PayoutPeriod{}
+	if err := row.Scan(&period.PeriodId, &period.From, &period.To, &period.ClosedAt); err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "payout period with id = '%v' not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	payouts, err := r.queryEntries("WHERE period_id = $1 AND kind = $2 ORDER BY courier_id", id, model.LedgerKindPayout)
+	if err != nil {
+		return nil, err
+	}
+	period.Payouts = payouts
+	return period, nil
+}
+
+func (r *LedgerRepository) queryEntries(condition string, args ...interface{}) ([]*model.LedgerEntry, error) {
+
+	rows, err := r.db.Query("SELECT "+ledgerColumns+" FROM courier_ledger "+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*model.LedgerEntry, 0)
+	for rows.Next() {
+		entry := &model.LedgerEntry{}
+		if err = rows.Scan(&entry.EntryId, &entry.CourierId, &entry.Kind, &entry.Reason, &entry.Amount, &entry.OrderId,
+			&entry.Comment, &entry.Date, &entry.PeriodId, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// insertOrderEntries stores entries written for orders in the transaction, an entry already written for the order is kept.
+// Entries dated in a closed payout period are dated today (UTC), periods cannot be closed until the transaction ends.
+func insertOrderEntries(tx *sql.Tx, data []service_data.NewLedgerEntryData) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec("LOCK TABLE payout_periods IN SHARE MODE"); err != nil {
+		return err
+	}
+	for i := 0; i < len(data); i++ {
+		_, err := tx.Exec(
+			`INSERT INTO courier_ledger(courier_id, kind, reason, amount, order_id, comment, entry_date)
+			SELECT $1,$2,$3,$4,$5,$6,
+				CASE WHEN EXISTS (SELECT 1 FROM payout_periods WHERE period_from <= $7::date AND period_to > $7::date)
+				THEN (now() AT TIME ZONE 'UTC')::date ELSE $7::date END
+			ON CONFLICT (order_id, kind, reason) WHERE order_id IS NOT NULL DO NOTHING`,
+			data[i].CourierId, data[i].Kind, data[i].Reason, data[i].Amount, data[i].OrderId, data[i].Comment, data[i].Date)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
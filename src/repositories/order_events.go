@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"Ya.SumSchool23/services/model"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+)
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+const orderEventColumns = "id, order_id, event_type, actor, payload, created_at"
+
+// insertOrderEvent appends an event to the order history, within the transaction of the transition if there is one.
+func insertOrderEvent(db execer, orderId int64, eventType, actor string, payload map[string]interface{}) error {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT INTO order_events(order_id, event_type, actor, payload) VALUES ($1,$2,$3,$4)",
+		orderId, eventType, actor, payloadJson)
+	return err
+}
+
+// orderCouriers returns the courier of the group order of every given order and of every order of the groups.
+func orderCouriers(db queryer, orderIds, groupIds []int64) (map[int64]int64, error) {
+	rows, err := db.Query(
+		`SELECT o.order_id, g.courier_id FROM orders o JOIN group_orders g ON g.id = o.group_order_id
+		WHERE o.order_id = ANY($1) OR o.group_order_id = ANY($2)`,
+		pq.Array(orderIds), pq.Array(groupIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	couriers := make(map[int64]int64)
+	for rows.Next() {
+		var orderId, courierId int64
+		if err = rows.Scan(&orderId, &courierId); err != nil {
+			return nil, err
+		}
+		couriers[orderId] = courierId
+	}
+	return couriers, rows.Err()
+}
+
+func scanOrderEvents(rows *sql.Rows) ([]*model.OrderEvent, error) {
+	events := make([]*model.OrderEvent, 0)
+	for rows.Next() {
+		event := &model.OrderEvent{}
+		if err := rows.Scan(&event.EventId, &event.OrderId, &event.Type, &event.Actor, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
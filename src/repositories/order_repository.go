@@ -6,6 +6,7 @@ import (
 	"Ya.SumSchool23/services/service_data"
 	"database/sql"
 	"github.com/lib/pq"
+	"time"
 )
 
 type OrderRepository struct {
@@ -20,17 +21,10 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 
 func (r *OrderRepository) GetOrderById(id int64) (*model.Order, error) {
 
-	row := r.db.QueryRow("SELECT order_id, weight, regions, delivery_hours, order_cost, completed_time FROM orders WHERE order_id = $1", id)
+	row := r.db.QueryRow("SELECT "+orderColumns+" FROM orders WHERE order_id = $1", id)
 
-	order := &model.Order{}
-	if err := row.Scan(
-		&order.OrderId,
-		&order.Weight,
-		&order.Regions,
-		pq.Array(&order.DeliveryHours),
-		&order.Cost,
-		&order.CompletedTime,
-	); err == sql.ErrNoRows {
+	order, err := scanOrder(row)
+	if err == sql.ErrNoRows {
 		return nil, cerrors.NotFound.Wrapf(err, "order with id = '%v' not found", id)
 	} else if err != nil {
 		return nil, err
@@ -38,53 +32,85 @@ func (r *OrderRepository) GetOrderById(id int64) (*model.Order, error) {
 	return order, nil
 }
 
-func (r *OrderRepository) GetOrders(limit, offset int64) ([]*model.Order, error) {
-
-	rows, err := r.db.Query("SELECT order_id, weight, regions, delivery_hours, order_cost, completed_time FROM orders ORDER BY order_id LIMIT $1 OFFSET $2",
-		limit, offset)
-	defer rows.Close()
+// GetOrders returns a page of orders, only of the delivery date if it is set.
+func (r *OrderRepository) GetOrders(limit, offset int64, deliveryDate *time.Time) ([]*model.Order, error) {
 
+	rows, err := r.db.Query(
+		"SELECT "+orderColumns+" FROM orders WHERE $3::date IS NULL OR delivery_date = $3 ORDER BY order_id LIMIT $1 OFFSET $2",
+		limit, offset, deliveryDate)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var orders []*model.Order
-	for rows.Next() {
-		order := &model.Order{}
-		err = rows.Scan(&order.OrderId, &order.Weight, &order.Regions, pq.Array(&order.DeliveryHours),
-			&order.Cost, &order.CompletedTime)
-		if err != nil {
-			return nil, err
-		}
-		orders = append(orders, order)
-	}
-	return orders, nil
+	return scanOrders(rows)
 }
 
-func (r *OrderRepository) CreateOrders(data []service_data.NewOrderData) ([]int64, error) {
+// CreateOrders inserts the orders with their created events and OrderCreated domain events in one transaction.
+func (r *OrderRepository) CreateOrders(data []service_data.NewOrderData, actor string) ([]int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
 	ids := make([]int64, len(data))
 
 	for i := 0; i < len(data); i++ {
-		row := r.db.QueryRow("INSERT INTO orders(weight, regions, delivery_hours,order_cost) VALUES ($1,$2,$3, $4) RETURNING order_id",
-			data[i].Weight, data[i].Regions, pq.StringArray(data[i].DeliveryHours), data[i].Cost)
+		row := tx.QueryRow(
+			`INSERT INTO orders(weight, regions, lat, lon, delivery_hours, order_cost, priority, promised_by, delivery_date, tariff_version)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING order_id`,
+			data[i].Weight, data[i].Regions, data[i].Lat, data[i].Lon, pq.StringArray(data[i].DeliveryHours), data[i].Cost,
+			data[i].Priority, data[i].PromisedBy, data[i].DeliveryDate, data[i].TariffVersion)
 		if err := row.Scan(&ids[i]); err != nil {
 			return nil, err
 		}
+		payload := map[string]interface{}{
+			"regions":       data[i].Regions,
+			"cost":          data[i].Cost,
+			"delivery_date": data[i].DeliveryDate,
+		}
+		if err := insertOrderEvent(tx, ids[i], model.OrderEventCreated, actor, payload); err != nil {
+			return nil, err
+		}
+		payload = map[string]interface{}{
+			"order_id":       ids[i],
+			"weight":         data[i].Weight,
+			"regions":        data[i].Regions,
+			"delivery_hours": data[i].DeliveryHours,
+			"cost":           data[i].Cost,
+			"delivery_date":  data[i].DeliveryDate,
+		}
+		if err := insertOutboxEvent(tx, model.EventOrderCreated, model.AggregateOrder, ids[i], payload); err != nil {
+			return nil, err
+		}
 	}
-	return ids, nil
+	return ids, tx.Commit()
 }
 
-func (r *OrderRepository) CreateCompleteOrder(data []service_data.NewCompleteOrderData) ([]int64, error) {
+// CreateCompleteOrder completes the orders that are not completed yet and records their completed and OrderCompleted events
+// and the ledger entries given for them, completing an order again is a no-op.
+func (r *OrderRepository) CreateCompleteOrder(
+	data []service_data.NewCompleteOrderData,
+	entries []service_data.NewLedgerEntryData,
+	actor string,
+) ([]int64, error) {
 
 	ids := make([]int64, len(data))
 	for i := 0; i < len(data); i++ {
 		ids[i] = data[i].OrderId
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var existingIdsCount int
-	ordersCountRow := r.db.QueryRow("select count(*) as sum from orders where order_id = ANY($1)", pq.Array(ids))
-	err := ordersCountRow.Scan(&existingIdsCount)
+	ordersCountRow := tx.QueryRow("select count(*) as sum from orders where order_id = ANY($1)", pq.Array(ids))
+	err = ordersCountRow.Scan(&existingIdsCount)
 	if err != nil {
 		return nil, err
 	}
@@ -92,21 +118,230 @@ func (r *OrderRepository) CreateCompleteOrder(data []service_data.NewCompleteOrd
 		return nil, cerrors.BadRequest.Wrapf(err, "some orders not found")
 	}
 
+	completed := make(map[int64]bool, len(data))
 	for i := 0; i < len(data); i++ {
 		var completedTime *string
-		row := r.db.QueryRow("select completed_time from orders where order_id = $1", data[i].OrderId)
+		row := tx.QueryRow("select completed_time from orders where order_id = $1 for update", data[i].OrderId)
 		if err = row.Scan(&completedTime); err != nil {
 			return nil, err
 		}
 
 		if completedTime == nil {
-			_, err = r.db.Exec("update orders set completed_time = $1, completed_courier_id = $2 where order_id = $3",
-				data[i].CompleteTime, data[i].CourierId, data[i].OrderId)
+			_, err = tx.Exec("update orders set completed_time = $1, completed_courier_id = $2, lateness_minutes = $3 where order_id = $4",
+				data[i].CompleteTime, data[i].CourierId, data[i].LatenessMinutes, data[i].OrderId)
 			if err != nil {
 				return nil, err
 			}
+			payload := map[string]interface{}{
+				"courier_id":       data[i].CourierId,
+				"complete_time":    data[i].CompleteTime,
+				"lateness_minutes": data[i].LatenessMinutes,
+			}
+			if err = insertOrderEvent(tx, data[i].OrderId, model.OrderEventCompleted, actor, payload); err != nil {
+				return nil, err
+			}
+			payload["order_id"] = data[i].OrderId
+			if err = insertOutboxEvent(tx, model.EventOrderCompleted, model.AggregateOrder, data[i].OrderId, payload); err != nil {
+				return nil, err
+			}
+			completed[data[i].OrderId] = true
+		}
+	}
+
+	completedEntries := make([]service_data.NewLedgerEntryData, 0, len(entries))
+	for _, entry := range entries {
+		if entry.OrderId != nil && completed[*entry.OrderId] {
+			completedEntries = append(completedEntries, entry)
+		}
+	}
+	if err = insertOrderEntries(tx, completedEntries); err != nil {
+		return nil, err
+	}
+
+	return ids, tx.Commit()
+}
+
+// GetOrderEvents returns the history of the order, oldest first.
+func (r *OrderRepository) GetOrderEvents(orderId int64) ([]*model.OrderEvent, error) {
+	rows, err := r.db.Query("SELECT "+orderEventColumns+" FROM order_events WHERE order_id = $1 ORDER BY id", orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanOrderEvents(rows)
+}
+
+// GetLatestOrderEvent returns the last event of the order, nil if it has none.
+func (r *OrderRepository) GetLatestOrderEvent(orderId int64) (*model.OrderEvent, error) {
+	rows, err := r.db.Query("SELECT "+orderEventColumns+" FROM order_events WHERE order_id = $1 ORDER BY id DESC LIMIT 1", orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events, err := scanOrderEvents(rows)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// GetOrdersByIds returns the existing orders of the ids, unknown ids are skipped.
+func (r *OrderRepository) GetOrdersByIds(ids []int64) ([]*model.Order, error) {
+	rows, err := r.db.Query("SELECT "+orderColumns+" FROM orders WHERE order_id = ANY($1) ORDER BY order_id", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanOrders(rows)
+}
+
+// GetUnassignedOrders returns orders for the delivery date that are neither completed nor in a group order.
+func (r *OrderRepository) GetUnassignedOrders(date time.Time) ([]*model.Order, error) {
+
+	rows, err := r.db.Query(
+		"SELECT "+orderColumns+" FROM orders WHERE completed_time IS NULL AND group_order_id IS NULL AND delivery_date = $1 ORDER BY order_id",
+		date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrders(rows)
+}
+
+// GetCompletedOrders returns orders completed by the courier with completion time in [from, to).
+func (r *OrderRepository) GetCompletedOrders(courierId int64, from, to time.Time) ([]*model.Order, error) {
+
+	rows, err := r.db.Query(
+		`SELECT `+orderColumns+` FROM orders
+		WHERE completed_courier_id = $1 AND completed_time::timestamptz >= $2 AND completed_time::timestamptz < $3
+		ORDER BY order_id`,
+		courierId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrders(rows)
+}
+
+// GetCourierLateRates counts orders completed in [from, to) and late ones per courier.
+func (r *OrderRepository) GetCourierLateRates(from, to time.Time) ([]*model.LateRate, error) {
+	return r.queryLateRates("completed_courier_id", from, to)
+}
+
+// GetRegionLateRates counts orders completed in [from, to) and late ones per region.
+func (r *OrderRepository) GetRegionLateRates(from, to time.Time) ([]*model.LateRate, error) {
+	return r.queryLateRates("regions", from, to)
+}
+
+func (r *OrderRepository) queryLateRates(column string, from, to time.Time) ([]*model.LateRate, error) {
+
+	rows, err := r.db.Query(
+		`SELECT `+column+`, count(*), count(*) FILTER (WHERE lateness_minutes > 0) FROM orders
+		WHERE lateness_minutes IS NOT NULL AND completed_time::timestamptz >= $1 AND completed_time::timestamptz < $2
+		GROUP BY `+column+` ORDER BY `+column+`::integer`,
+		from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]*model.LateRate, 0)
+	for rows.Next() {
+		rate := &model.LateRate{}
+		if err = rows.Scan(&rate.Id, &rate.Completed, &rate.Late); err != nil {
+			return nil, err
 		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+// CreateFeedback stores the feedback of the completed order, an order gets feedback once.
+func (r *OrderRepository) CreateFeedback(data service_data.NewOrderFeedbackData, courierId int64) error {
+
+	_, err := r.db.Exec("INSERT INTO order_feedback(order_id, courier_id, score, comment) VALUES ($1,$2,$3,$4)",
+		data.OrderId, courierId, data.Score, data.Comment)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+		return cerrors.BadRequest.Wrapf(err, "order with id = '%v' already has feedback", data.OrderId)
+	}
+	return err
+}
+
+func (r *OrderRepository) GetFeedback(orderId int64) (*model.OrderFeedback, error) {
+
+	row := r.db.QueryRow("SELECT order_id, courier_id, score, comment, created_at FROM order_feedback WHERE order_id = $1", orderId)
+
+	feedback := &model.OrderFeedback{}
+	err := row.Scan(&feedback.OrderId, &feedback.CourierId, &feedback.Score, &feedback.Comment, &feedback.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "feedback of order with id = '%v' not found", orderId)
+	} else if err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// GetQuality returns the average feedback score of orders the courier completed in [from, to), nil without feedback.
+func (r *OrderRepository) GetQuality(courierId int64, from, to time.Time) (*model.Quality, error) {
+
+	row := r.db.QueryRow(
+		`SELECT coalesce(avg(f.score), 0), count(*) FROM order_feedback f JOIN orders o ON o.order_id = f.order_id
+		WHERE f.courier_id = $1 AND o.completed_time::timestamptz >= $2 AND o.completed_time::timestamptz < $3`,
+		courierId, from, to)
+
+	quality := &model.Quality{}
+	if err := row.Scan(&quality.Score, &quality.Feedbacks); err != nil {
+		return nil, err
+	}
+	if quality.Feedbacks == 0 {
+		return nil, nil
+	}
+	return quality, nil
+}
+
+const orderColumns = "order_id, weight, regions, lat, lon, delivery_hours, order_cost, completed_time, group_order_id, planned_arrival, pinned, priority, promised_by, lateness_minutes, delivery_date, tariff_version, completed_courier_id"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row rowScanner) (*model.Order, error) {
+	order := &model.Order{}
+	err := row.Scan(
+		&order.OrderId,
+		&order.Weight,
+		&order.Regions,
+		&order.Lat,
+		&order.Lon,
+		pq.Array(&order.DeliveryHours),
+		&order.Cost,
+		&order.CompletedTime,
+		&order.GroupOrderId,
+		&order.PlannedArrival,
+		&order.Pinned,
+		&order.Priority,
+		&order.PromisedBy,
+		&order.LatenessMinutes,
+		&order.DeliveryDate,
+		&order.TariffVersion,
+		&order.CompletedCourierId,
+	)
+	if err != nil {
+		return nil, err
 	}
+	return order, nil
+}
 
-	return ids, nil
+func scanOrders(rows *sql.Rows) ([]*model.Order, error) {
+	var orders []*model.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
 }
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"log"
+	"time"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// insertOutboxEvent stores a domain event, within the transaction of the change it describes.
+func insertOutboxEvent(db execer, eventType, aggregateType string, aggregateId int64, payload map[string]interface{}) error {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT INTO outbox(event_type, aggregate_type, aggregate_id, payload) VALUES ($1,$2,$3,$4)",
+		eventType, aggregateType, aggregateId, payloadJson)
+	return err
+}
+
+// publishableEvents are the events of transactions older than every transaction in progress. An event of a transaction
+// in progress may get a lower id than an event committed already, the order of publishable events does not change.
+const publishableEvents = "tx_id < pg_snapshot_xmin(pg_current_snapshot())"
+
+// ClaimRelay leases the relay so that other instances of it wait, nil if another instance holds the lease.
+// A relay of an instance that stopped before releasing it is free again once the lease is over.
+// A new relay starts after the last publishable event.
+func (r *OutboxRepository) ClaimRelay(name string, lease time.Duration) (*model.OutboxRelayState, error) {
+	last, err := r.GetLastPosition()
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.Exec("INSERT INTO outbox_relays(name, last_tx_id, last_event_id) VALUES ($1,$2::xid8,$3) ON CONFLICT (name) DO NOTHING",
+		name, last.TxId, last.EventId)
+	if err != nil {
+		return nil, err
+	}
+
+	row := r.db.QueryRow(
+		`UPDATE outbox_relays SET leased_until = now() + $2 * interval '1 millisecond'
+		WHERE name = $1 AND (leased_until IS NULL OR leased_until <= now())
+		RETURNING last_tx_id::text, last_event_id, attempts, last_error`,
+		name, lease.Milliseconds())
+	state := &model.OutboxRelayState{Name: name}
+	err = row.Scan(&state.TxId, &state.EventId, &state.Attempts, &state.LastError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return state, err
+}
+
+// GetRelayEvents returns up to limit of the publishable events after the position, in order.
+func (r *OutboxRepository) GetRelayEvents(after model.OutboxPosition, limit int) ([]*model.OutboxEvent, error) {
+	rows, err := r.db.Query(
+		`SELECT id, tx_id::text, event_type, aggregate_type, aggregate_id, payload, created_at FROM outbox
+		WHERE (tx_id, id) > ($1::xid8, $2) AND `+publishableEvents+`
+		ORDER BY tx_id, id LIMIT $3`,
+		after.TxId, after.EventId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*model.OutboxEvent, 0)
+	for rows.Next() {
+		event := &model.OutboxEvent{}
+		err = rows.Scan(&event.Id, &event.TxId, &event.Type, &event.AggregateType, &event.AggregateId, &event.Payload,
+			&event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ReleaseRelay stores how far the relay got and ends its lease, after a failed attempt the relay
+// stays leased for the backoff so that no instance retries before.
+func (r *OutboxRepository) ReleaseRelay(state *model.OutboxRelayState, backoff time.Duration) error {
+
+	_, err := r.db.Exec(
+		`UPDATE outbox_relays SET last_tx_id = $2::xid8, last_event_id = $3, attempts = $4, last_error = $5,
+		leased_until = CASE WHEN $6::bigint > 0 THEN now() + $6::bigint * interval '1 millisecond' END
+		WHERE name = $1`,
+		state.Name, state.TxId, state.EventId, state.Attempts, state.LastError, backoff.Milliseconds())
+	return err
+}
+
+// outboxChannel is notified with the id of every event inserted into the outbox.
+const outboxChannel = "outbox_events"
+
+// GetLastPosition returns the position of the last publishable event, the start of the outbox if there is none.
+func (r *OutboxRepository) GetLastPosition() (model.OutboxPosition, error) {
+	var position model.OutboxPosition
+	row := r.db.QueryRow(
+		`SELECT tx_id::text, id FROM (
+			SELECT tx_id, id FROM outbox WHERE ` + publishableEvents + `
+			UNION ALL SELECT '0'::xid8, 0
+		) e ORDER BY tx_id DESC, id DESC LIMIT 1`)
+	return position, row.Scan(&position.TxId, &position.EventId)
+}
+
+// GetEventPosition returns the position of the event.
+func (r *OutboxRepository) GetEventPosition(id int64) (model.OutboxPosition, error) {
+	position := model.OutboxPosition{EventId: id}
+	row := r.db.QueryRow("SELECT tx_id::text FROM outbox WHERE id = $1", id)
+	if err := row.Scan(&position.TxId); err == sql.ErrNoRows {
+		return position, cerrors.NotFound.Wrapf(err, "event with id = '%v' not found", id)
+	} else if err != nil {
+		return position, err
+	}
+	return position, nil
+}
+
+// GetStreamEvents returns up to limit of the publishable events after the position, in order.
+func (r *OutboxRepository) GetStreamEvents(after model.OutboxPosition, limit int) ([]*model.StreamEvent, error) {
+	rows, err := r.db.Query(
+		`SELECT e.id, e.tx_id::text, e.event_type, e.aggregate_type, e.aggregate_id, e.payload, e.created_at,
+			CASE WHEN e.aggregate_type = $4 THEN ARRAY[o.regions] ELSE c.regions END,
+			(e.payload ->> 'courier_id')::bigint
+		FROM outbox e
+		LEFT JOIN orders o ON e.aggregate_type = $4 AND o.order_id = e.aggregate_id
+		LEFT JOIN couriers c ON e.aggregate_type = $5 AND c.id = e.aggregate_id
+		WHERE (e.tx_id, e.id) > ($1::xid8, $2) AND e.tx_id < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY e.tx_id, e.id LIMIT $3`,
+		after.TxId, after.EventId, limit, model.AggregateOrder, model.AggregateCourier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*model.StreamEvent, 0)
+	for rows.Next() {
+		event := &model.StreamEvent{}
+		err = rows.Scan(&event.Id, &event.TxId, &event.Type, &event.AggregateType, &event.AggregateId, &event.Payload,
+			&event.CreatedAt, (*pq.Int64Array)(&event.Regions), &event.CourierId)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ListenOutbox calls notify on every event inserted into the outbox and after every reconnect of the listener,
+// when notifications may have been missed.
+func ListenOutbox(connStr string, notify func()) (*pq.Listener, error) {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("outbox listener: %s", err.Error())
+		}
+	})
+	if err := listener.Listen(outboxChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	go func() {
+		for range listener.Notify {
+			notify()
+		}
+	}()
+	return listener, nil
+}
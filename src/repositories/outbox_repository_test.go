@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"Ya.SumSchool23/services/model"
+	"database/sql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// testDb connects to the migrated database of TEST_POSTGRES_DSN, the test is skipped without one.
+func testDb(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	require.NoError(t, err)
+	m, err := migrate.NewWithDatabaseInstance("file://../../migrations", "postgres", driver)
+	require.NoError(t, err)
+	if err = m.Up(); err != migrate.ErrNoChange {
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestStreamEventsCommittedOutOfOrder(t *testing.T) {
+	db := testDb(t)
+	r := NewOutboxRepository(db)
+	start, err := r.GetLastPosition()
+	require.NoError(t, err)
+
+	// the later transaction starts first and stores its event after the earlier one did
+	later, err := db.Begin()
+	require.NoError(t, err)
+	defer later.Rollback()
+	_, err = later.Exec("SELECT pg_current_xact_id()")
+	require.NoError(t, err)
+
+	earlier, err := db.Begin()
+	require.NoError(t, err)
+	defer earlier.Rollback()
+	require.NoError(t, insertOutboxEvent(earlier, model.EventCourierCreated, model.AggregateCourier, -1, nil))
+	require.NoError(t, insertOutboxEvent(later, model.EventCourierCreated, model.AggregateCourier, -2, nil))
+	require.NoError(t, later.Commit())
+
+	events, err := r.GetStreamEvents(start, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(-2), events[0].AggregateId)
+	cursor := model.OutboxPosition{TxId: events[0].TxId, EventId: events[0].Id}
+
+	// the event of the lower id committed last still comes after the cursor
+	require.NoError(t, earlier.Commit())
+	events, err = r.GetStreamEvents(cursor, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(-1), events[0].AggregateId)
+	require.Less(t, events[0].Id, cursor.EventId)
+
+	// a transaction in progress holds back the events committed after it started
+	inProgress, err := db.Begin()
+	require.NoError(t, err)
+	defer inProgress.Rollback()
+	require.NoError(t, insertOutboxEvent(inProgress, model.EventCourierCreated, model.AggregateCourier, -3, nil))
+	require.NoError(t, insertOutboxEvent(db, model.EventCourierCreated, model.AggregateCourier, -4, nil))
+
+	cursor = model.OutboxPosition{TxId: events[0].TxId, EventId: events[0].Id}
+	events, err = r.GetStreamEvents(cursor, 10)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	require.NoError(t, inProgress.Commit())
+	events, err = r.GetStreamEvents(cursor, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, int64(-3), events[0].AggregateId)
+	require.Equal(t, int64(-4), events[1].AggregateId)
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+type RegionRepository struct {
+	db *sql.DB
+}
+
+func NewRegionRepository(db *sql.DB) *RegionRepository {
+	return &RegionRepository{
+		db: db,
+	}
+}
+
+func (r *RegionRepository) GetRegions(limit, offset int64) ([]*model.Region, error) {
+
+	rows, err := r.db.Query("SELECT id, name, time_zone, active, boundary FROM regions ORDER BY id LIMIT $1 OFFSET $2",
+		limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRegions(rows)
+}
+
+// GetRegionsWithBoundary returns all regions that have a boundary.
+func (r *RegionRepository) GetRegionsWithBoundary() ([]*model.Region, error) {
+
+	rows, err := r.db.Query("SELECT id, name, time_zone, active, boundary FROM regions WHERE boundary IS NOT NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRegions(rows)
+}
+
+func (r *RegionRepository) GetRegionsByIds(ids []int64) ([]*model.Region, error) {
+
+	rows, err := r.db.Query("SELECT id, name, time_zone, active, boundary FROM regions WHERE id = ANY($1) ORDER BY id",
+		pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRegions(rows)
+}
+
+func (r *RegionRepository) GetRegionById(id int64) (*model.Region, error) {
+
+	row := r.db.QueryRow("SELECT id, name, time_zone, active, boundary FROM regions WHERE id = $1", id)
+
+	region := &model.Region{}
+	if err := row.Scan(&region.RegionId, &region.Name, &region.TimeZone, &region.Active, &region.Boundary); err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "region with id = '%v' not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+	return region, nil
+}
+
+func (r *RegionRepository) CreateRegion(data service_data.NewRegionData) error {
+
+	_, err := r.db.Exec("INSERT INTO regions(id, name, time_zone, active, boundary) VALUES ($1,$2,$3,$4,$5)",
+		data.RegionId, data.Name, data.TimeZone, data.Active, nullableJson(data.Boundary))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+		return cerrors.BadRequest.Wrapf(err, "region with id = '%v' already exists", data.RegionId)
+	}
+	return err
+}
+
+func (r *RegionRepository) UpdateRegion(data service_data.NewRegionData) error {
+
+	res, err := r.db.Exec("UPDATE regions SET name = $1, time_zone = $2, active = $3, boundary = $4 WHERE id = $5",
+		data.Name, data.TimeZone, data.Active, nullableJson(data.Boundary), data.RegionId)
+	if err != nil {
+		return err
+	}
+	return checkRegionAffected(res, data.RegionId)
+}
+
+func (r *RegionRepository) DeleteRegion(id int64) error {
+
+	res, err := r.db.Exec("DELETE FROM regions WHERE id = $1", id)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+		return cerrors.BadRequest.Wrapf(err, "region with id = '%v' is still in use", id)
+	} else if err != nil {
+		return err
+	}
+	return checkRegionAffected(res, id)
+}
+
+func (r *RegionRepository) GetRegionEdges() ([]*model.RegionEdge, error) {
+
+	rows, err := r.db.Query("SELECT from_region, to_region, courier_type, minutes FROM region_edges ORDER BY from_region, to_region")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []*model.RegionEdge
+	for rows.Next() {
+		edge := &model.RegionEdge{}
+		if err = rows.Scan(&edge.FromRegion, &edge.ToRegion, &edge.CourierType, &edge.Minutes); err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+	return edges, rows.Err()
+}
+
+// nullableJson maps empty json to NULL, lib/pq sends []byte as bytea otherwise.
+func nullableJson(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+func checkRegionAffected(res sql.Result, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return cerrors.NotFound.Newf("region with id = '%v' not found", id)
+	}
+	return nil
+}
+
+func scanRegions(rows *sql.Rows) ([]*model.Region, error) {
+	var regions []*model.Region
+	for rows.Next() {
+		region := &model.Region{}
+		if err := rows.Scan(&region.RegionId, &region.Name, &region.TimeZone, &region.Active, &region.Boundary); err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+	return regions, rows.Err()
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"github.com/lib/pq"
+	"time"
+)
+
+type ScheduleRepository struct {
+	db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+	return &ScheduleRepository{
+		db: db,
+	}
+}
+
+func (r *ScheduleRepository) GetWeeklySchedules(courierIds []int64) ([]*model.CourierSchedule, error) {
+
+	rows, err := r.db.Query(
+		"SELECT courier_id, weekday, working_hours FROM courier_schedules WHERE courier_id = ANY($1) ORDER BY courier_id, weekday",
+		pq.Array(courierIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*model.CourierSchedule
+	for rows.Next() {
+		schedule := &model.CourierSchedule{}
+		if err = rows.Scan(&schedule.CourierId, &schedule.Weekday, pq.Array(&schedule.WorkingHours)); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// ReplaceWeeklySchedule removes the weekday schedules of the courier and stores the new ones
+// with a CourierScheduleUpdated event.
+func (r *ScheduleRepository) ReplaceWeeklySchedule(courierId int64, data []service_data.NewCourierScheduleData) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec("DELETE FROM courier_schedules WHERE courier_id = $1", courierId); err != nil {
+		return err
+	}
+	for i := 0; i < len(data); i++ {
+		_, err = tx.Exec("INSERT INTO courier_schedules(courier_id, weekday, working_hours) VALUES ($1,$2,$3)",
+			courierId, data[i].Weekday, pq.StringArray(data[i].WorkingHours))
+		if err != nil {
+			return err
+		}
+	}
+	schedule := make([]map[string]interface{}, len(data))
+	for i := 0; i < len(data); i++ {
+		schedule[i] = map[string]interface{}{"weekday": data[i].Weekday, "working_hours": data[i].WorkingHours}
+	}
+	payload := map[string]interface{}{"courier_id": courierId, "weekly_schedule": schedule}
+	if err = insertOutboxEvent(tx, model.EventCourierScheduleUpdated, model.AggregateCourier, courierId, payload); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetOverrides returns overrides of the couriers with dates in [from, to).
+func (r *ScheduleRepository) GetOverrides(courierIds []int64, from, to time.Time) ([]*model.ScheduleOverride, error) {
+
+	rows, err := r.db.Query(
+		`SELECT id, courier_id, override_date, kind, working_hours, comment FROM courier_schedule_overrides
+		WHERE courier_id = ANY($1) AND override_date >= $2 AND override_date < $3
+		ORDER BY override_date, id`,
+		pq.Array(courierIds), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []*model.ScheduleOverride
+	for rows.Next() {
+		override := &model.ScheduleOverride{}
+		if err = rows.Scan(&override.OverrideId, &override.CourierId, &override.Date, &override.Kind,
+			pq.Array(&override.WorkingHours), &override.Comment); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, rows.Err()
+}
+
+func (r *ScheduleRepository) GetOverrideById(id int64) (*model.ScheduleOverride, error) {
+
+	row := r.db.QueryRow(
+		"SELECT id, courier_id, override_date, kind, working_hours, comment FROM courier_schedule_overrides WHERE id = $1", id)
+
+	override := &model.ScheduleOverride{}
+	if err := row.Scan(
+		&override.OverrideId,
+		&override.CourierId,
+		&override.Date,
+		&override.Kind,
+		pq.Array(&override.WorkingHours),
+		&override.Comment,
+	); err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "schedule override with id = '%v' not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// CreateOverride stores the override with a CourierScheduleUpdated event.
+func (r *ScheduleRepository) CreateOverride(data service_data.NewScheduleOverrideData) (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	row := tx.QueryRow(
+		`INSERT INTO courier_schedule_overrides(courier_id, override_date, kind, working_hours, comment)
+		VALUES ($1,$2,$3,$4,$5) RETURNING id`,
+		data.CourierId, data.Date, data.Kind, pq.StringArray(data.WorkingHours), data.Comment)
+	if err = row.Scan(&id); err != nil {
+		return 0, err
+	}
+	payload := map[string]interface{}{
+		"courier_id":    data.CourierId,
+		"override_id":   id,
+		"date":          data.Date.Format("2006-01-02"),
+		"kind":          data.Kind,
+		"working_hours": data.WorkingHours,
+	}
+	if err = insertOutboxEvent(tx, model.EventCourierScheduleUpdated, model.AggregateCourier, data.CourierId, payload); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// DeleteOverride removes the override with a CourierScheduleUpdated event.
+func (r *ScheduleRepository) DeleteOverride(courierId, overrideId int64) error {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var date time.Time
+	row := tx.QueryRow("DELETE FROM courier_schedule_overrides WHERE id = $1 AND courier_id = $2 RETURNING override_date",
+		overrideId, courierId)
+	if err = row.Scan(&date); err == sql.ErrNoRows {
+		return cerrors.NotFound.Newf("schedule override with id = '%v' not found for courier '%v'", overrideId, courierId)
+	} else if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"courier_id":  courierId,
+		"override_id": overrideId,
+		"date":        date.Format("2006-01-02"),
+		"deleted":     true,
+	}
+	if err = insertOutboxEvent(tx, model.EventCourierScheduleUpdated, model.AggregateCourier, courierId, payload); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
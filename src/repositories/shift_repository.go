@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"database/sql"
+	"time"
+)
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{
+		db: db,
+	}
+}
+
+func (r *ShiftRepository) GetShiftById(id int64) (*model.Shift, error) {
+
+	row := r.db.QueryRow("SELECT id, courier_id, started_at, ended_at FROM courier_shifts WHERE id = $1", id)
+
+	shift := &model.Shift{}
+	if err := row.Scan(&shift.ShiftId, &shift.CourierId, &shift.StartedAt, &shift.EndedAt); err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "shift with id = '%v' not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// GetShifts returns shifts of the courier overlapping [from, to).
+func (r *ShiftRepository) GetShifts(courierId int64, from, to time.Time) ([]*model.Shift, error) {
+
+	rows, err := r.db.Query(
+		`SELECT id, courier_id, started_at, ended_at FROM courier_shifts
+		WHERE courier_id = $1 AND started_at < $3 AND (ended_at IS NULL OR ended_at > $2)
+		ORDER BY started_at`,
+		courierId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shifts []*model.Shift
+	for rows.Next() {
+		shift := &model.Shift{}
+		if err = rows.Scan(&shift.ShiftId, &shift.CourierId, &shift.StartedAt, &shift.EndedAt); err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+	return shifts, rows.Err()
+}
+
+// StartShift opens a shift of the courier with a CourierShiftStarted event.
+func (r *ShiftRepository) StartShift(courierId int64, at time.Time) (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var openShifts int
+	row := tx.QueryRow("SELECT count(*) FROM courier_shifts WHERE courier_id = $1 AND ended_at IS NULL", courierId)
+	if err = row.Scan(&openShifts); err != nil {
+		return 0, err
+	}
+	if openShifts > 0 {
+		return 0, cerrors.BadRequest.Newf("courier with id = '%v' is already checked in", courierId)
+	}
+
+	var id int64
+	row = tx.QueryRow("INSERT INTO courier_shifts(courier_id, started_at) VALUES ($1,$2) RETURNING id", courierId, at)
+	if err = row.Scan(&id); err != nil {
+		return 0, err
+	}
+	payload := map[string]interface{}{"courier_id": courierId, "shift_id": id, "started_at": at}
+	if err = insertOutboxEvent(tx, model.EventCourierShiftStarted, model.AggregateCourier, courierId, payload); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// EndShift closes the open shift of the courier with a CourierShiftEnded event.
+func (r *ShiftRepository) EndShift(courierId int64, at time.Time) (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	row := tx.QueryRow("UPDATE courier_shifts SET ended_at = $1 WHERE courier_id = $2 AND ended_at IS NULL RETURNING id",
+		at, courierId)
+	if err = row.Scan(&id); err == sql.ErrNoRows {
+		return 0, cerrors.BadRequest.Wrapf(err, "courier with id = '%v' is not checked in", courierId)
+	} else if err != nil {
+		return 0, err
+	}
+	payload := map[string]interface{}{"courier_id": courierId, "shift_id": id, "ended_at": at}
+	if err = insertOutboxEvent(tx, model.EventCourierShiftEnded, model.AggregateCourier, courierId, payload); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// GetCheckedInCourierIds returns ids of couriers with an open shift.
+func (r *ShiftRepository) GetCheckedInCourierIds() ([]int64, error) {
+
+	rows, err := r.db.Query("SELECT courier_id FROM courier_shifts WHERE ended_at IS NULL ORDER BY courier_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+type TariffRepository struct {
+	db *sql.DB
+}
+
+func NewTariffRepository(db *sql.DB) *TariffRepository {
+	return &TariffRepository{
+		db: db,
+	}
+}
+
+// CreateTariff stores a new tariff version with its zone prices and surges.
+func (r *TariffRepository) CreateTariff(data service_data.NewTariffData) (int64, error) {
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var version int64
+	row := tx.QueryRow(
+		`INSERT INTO tariffs(base_price, per_kg_price, narrow_window_minutes, narrow_window_surcharge)
+		VALUES ($1,$2,$3,$4) RETURNING version`,
+		data.BasePrice, data.PerKgPrice, data.NarrowWindowMinutes, data.NarrowWindowSurcharge)
+	if err = row.Scan(&version); err != nil {
+		return 0, err
+	}
+
+	for _, zone := range data.ZonePrices {
+		_, err = tx.Exec("INSERT INTO tariff_zone_prices(tariff_version, region_id, base_price) VALUES ($1,$2,$3)",
+			version, zone.RegionId, zone.BasePrice)
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, surge := range data.Surges {
+		_, err = tx.Exec("INSERT INTO tariff_surges(tariff_version, time_interval, multiplier) VALUES ($1,$2,$3)",
+			version, surge.Interval, surge.Multiplier)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return version, tx.Commit()
+}
+
+func (r *TariffRepository) GetTariffByVersion(version int64) (*model.Tariff, error) {
+	tariffs, err := r.queryTariffs("WHERE version = $1", version)
+	if err != nil {
+		return nil, err
+	}
+	if len(tariffs) == 0 {
+		return nil, cerrors.NotFound.Newf("tariff with version = '%v' not found", version)
+	}
+	return tariffs[0], nil
+}
+
+// GetCurrentTariff returns the latest tariff version, nil if there are no tariffs.
+func (r *TariffRepository) GetCurrentTariff() (*model.Tariff, error) {
+	tariffs, err := r.queryTariffs("WHERE version = (SELECT max(version) FROM tariffs)")
+	if err != nil || len(tariffs) == 0 {
+		return nil, err
+	}
+	return tariffs[0], nil
+}
+
+func (r *TariffRepository) GetTariffs(limit, offset int64) ([]*model.Tariff, error) {
+	return r.queryTariffs("ORDER BY version LIMIT $1 OFFSET $2", limit, offset)
+}
+
+func (r *TariffRepository) queryTariffs(condition string, args ...interface{}) ([]*model.Tariff, error) {
+
+	rows, err := r.db.Query(
+		`SELECT version, base_price, per_kg_price, narrow_window_minutes, narrow_window_surcharge, created_at
+		FROM tariffs `+condition,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tariffs := make([]*model.Tariff, 0)
+	byVersion := make(map[int64]*model.Tariff)
+	for rows.Next() {
+		tariff := &model.Tariff{ZonePrices: make([]model.TariffZonePrice, 0), Surges: make([]model.TariffSurge, 0)}
+		if err = rows.Scan(&tariff.Version, &tariff.BasePrice, &tariff.PerKgPrice, &tariff.NarrowWindowMinutes,
+			&tariff.NarrowWindowSurcharge, &tariff.CreatedAt); err != nil {
+			return nil, err
+		}
+		tariffs = append(tariffs, tariff)
+		byVersion[tariff.Version] = tariff
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tariffs) == 0 {
+		return tariffs, nil
+	}
+
+	versions := make([]int64, len(tariffs))
+	for i, tariff := range tariffs {
+		versions[i] = tariff.Version
+	}
+	zoneRows, err := r.db.Query(
+		"SELECT tariff_version, region_id, base_price FROM tariff_zone_prices WHERE tariff_version = ANY($1) ORDER BY region_id",
+		pq.Array(versions))
+	if err != nil {
+		return nil, err
+	}
+	defer zoneRows.Close()
+	for zoneRows.Next() {
+		var version int64
+		var zone model.TariffZonePrice
+		if err = zoneRows.Scan(&version, &zone.RegionId, &zone.BasePrice); err != nil {
+			return nil, err
+		}
+		byVersion[version].ZonePrices = append(byVersion[version].ZonePrices, zone)
+	}
+	if err = zoneRows.Err(); err != nil {
+		return nil, err
+	}
+
+	surgeRows, err := r.db.Query(
+		"SELECT tariff_version, time_interval, multiplier FROM tariff_surges WHERE tariff_version = ANY($1) ORDER BY time_interval",
+		pq.Array(versions))
+	if err != nil {
+		return nil, err
+	}
+	defer surgeRows.Close()
+	for surgeRows.Next() {
+		var version int64
+		var surge model.TariffSurge
+		if err = surgeRows.Scan(&version, &surge.Interval, &surge.Multiplier); err != nil {
+			return nil, err
+		}
+		byVersion[version].Surges = append(byVersion[version].Surges, surge)
+	}
+	return tariffs, surgeRows.Err()
+}
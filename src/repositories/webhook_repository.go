@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"database/sql"
+	"github.com/lib/pq"
+	"time"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+const webhookColumns = "id, url, event_types, secret, active, created_at"
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, body, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at"
+
+func (r *WebhookRepository) CreateWebhook(data service_data.NewWebhookData) (int64, error) {
+	var id int64
+	row := r.db.QueryRow("INSERT INTO webhooks(url, event_types, secret, active) VALUES ($1,$2,$3,$4) RETURNING id",
+		data.Url, pq.StringArray(data.EventTypes), data.Secret, data.Active)
+	return id, row.Scan(&id)
+}
+
+func (r *WebhookRepository) GetWebhooks(limit, offset int64) ([]*model.Webhook, error) {
+	rows, err := r.db.Query("SELECT "+webhookColumns+" FROM webhooks ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) GetWebhookById(id int64) (*model.Webhook, error) {
+	webhook, err := scanWebhook(r.db.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, cerrors.NotFound.Wrapf(err, "webhook with id = '%v' not found", id)
+	}
+	return webhook, err
+}
+
+// UpdateWebhook changes the url, event types and state of the webhook, the secret is kept.
+func (r *WebhookRepository) UpdateWebhook(data service_data.NewWebhookData) error {
+	res, err := r.db.Exec("UPDATE webhooks SET url = $1, event_types = $2, active = $3 WHERE id = $4",
+		data.Url, pq.StringArray(data.EventTypes), data.Active, data.WebhookId)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, "webhook", data.WebhookId)
+}
+
+// DeleteWebhook removes the webhook with its deliveries.
+func (r *WebhookRepository) DeleteWebhook(id int64) error {
+	res, err := r.db.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, "webhook", id)
+}
+
+// EnqueueDeliveries adds a delivery of the event to every active webhook subscribed to its type,
+// an event already enqueued for a webhook is skipped.
+func (r *WebhookRepository) EnqueueDeliveries(event *model.OutboxEvent, body []byte) error {
+	_, err := r.db.Exec(
+		`INSERT INTO webhook_deliveries(webhook_id, event_id, event_type, body)
+		SELECT id, $1, $2, $3 FROM webhooks WHERE active AND $2 = ANY(event_types)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		event.Id, event.Type, body)
+	return err
+}
+
+// ClaimDueDeliveries returns up to limit of the pending deliveries due now, oldest first, and postpones them by the lease
+// so that concurrent workers skip them. A delivery of a worker that stopped before recording the attempt is due again
+// once the lease is over.
+func (r *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	rows, err := r.db.Query(
+		`UPDATE webhook_deliveries SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = $3 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING `+deliveryColumns,
+		limit, lease.Milliseconds(), model.DeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanDeliveries(rows)
+}
+
+func (r *WebhookRepository) RecordAttempt(data service_data.NewDeliveryAttemptData) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5,
+		delivered_at = CASE WHEN $1 = $6 THEN now() END
+		WHERE id = $7`,
+		data.Status, data.Attempts, data.NextAttemptAt, data.LastStatusCode, data.LastError, model.DeliveryDelivered, data.DeliveryId)
+	return err
+}
+
+// GetDeliveries returns deliveries of the webhook newest first, only of the status if it is set.
+func (r *WebhookRepository) GetDeliveries(webhookId int64, status string, limit, offset int64) ([]*model.WebhookDelivery, error) {
+	rows, err := r.db.Query(
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = $1 AND ($2 = '' OR status = $2) ORDER BY id DESC LIMIT $3 OFFSET $4",
+		webhookId, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanDeliveries(rows)
+}
+
+func requireAffected(res sql.Result, entity string, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return cerrors.NotFound.Newf("%s with id = '%v' not found", entity, id)
+	}
+	return nil
+}
+
+func scanWebhook(row rowScanner) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	err := row.Scan(&webhook.WebhookId, &webhook.Url, (*pq.StringArray)(&webhook.EventTypes), &webhook.Secret,
+		&webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]*model.WebhookDelivery, error) {
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := &model.WebhookDelivery{}
+		err := rows.Scan(&delivery.DeliveryId, &delivery.WebhookId, &delivery.EventId, &delivery.EventType, &delivery.Body,
+			&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError,
+			&delivery.CreatedAt, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,42 @@
+package routing
+
+import (
+	"Ya.SumSchool23/services/model"
+)
+
+// Estimator estimates how long a courier needs to deliver a group of orders.
+// Every order takes the per-type handling minutes of the courier profile, and moving
+// to a stop in another region adds the travel time over the region graph.
+// With an empty graph all regions are treated as equally far, so only the handling minutes count.
+type Estimator struct {
+	graph *Graph
+}
+
+func NewEstimator(graph *Graph) *Estimator {
+	return &Estimator{
+		graph: graph,
+	}
+}
+
+func (e *Estimator) Graph() *Graph {
+	return e.graph
+}
+
+// LegMinutes returns the minutes from finishing the previous stop to finishing the stop in the region.
+// previous is nil for the first stop of a group, the second result is false if the region cannot be reached.
+func (e *Estimator) LegMinutes(courierType string, previous *int64, region int64) (int64, bool) {
+	profile := model.CourierTypeProfiles[courierType]
+	if previous == nil {
+		return int64(profile.FirstOrderMinutes), true
+	}
+
+	minutes := int64(profile.NextOrderMinutes)
+	if e.graph.IsEmpty() {
+		return minutes, true
+	}
+	travel, ok := e.graph.TravelMinutes(*previous, region, courierType)
+	if !ok {
+		return 0, false
+	}
+	return minutes + travel, true
+}
@@ -0,0 +1,166 @@
+package routing
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Edge is a direct connection between two regions with travel minutes per courier type.
+type Edge struct {
+	From    int64            `json:"from"`
+	To      int64            `json:"to"`
+	Minutes map[string]int64 `json:"minutes"`
+}
+
+type graphFile struct {
+	Edges []Edge `json:"edges"`
+}
+
+// Graph is an undirected region adjacency graph. Travel time between regions that are not
+// neighbours is the shortest path over the graph, computed on demand and cached.
+// Edges must not be added after the graph is in use.
+type Graph struct {
+	edges map[int64]map[int64]map[string]int64
+
+	cache      map[string]map[int64]map[int64]int64
+	cacheMutex sync.Mutex
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		edges: make(map[int64]map[int64]map[string]int64),
+		cache: make(map[string]map[int64]map[int64]int64),
+	}
+}
+
+// LoadGraphFile reads a graph from a JSON file like {"edges": [{"from": 1, "to": 2, "minutes": {"FOOT": 20}}]}.
+func LoadGraphFile(path string) (*Graph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read region graph file: %w", err)
+	}
+
+	var file graphFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse region graph file: %w", err)
+	}
+
+	graph := NewGraph()
+	for _, edge := range file.Edges {
+		for courierType, minutes := range edge.Minutes {
+			if err = graph.AddEdge(edge.From, edge.To, courierType, minutes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return graph, nil
+}
+
+func (g *Graph) AddEdge(from, to int64, courierType string, minutes int64) error {
+	if from == to {
+		return fmt.Errorf("region %d cannot be connected to itself", from)
+	}
+	if minutes < 0 {
+		return fmt.Errorf("travel time between regions %d and %d must not be negative", from, to)
+	}
+	g.addDirected(from, to, courierType, minutes)
+	g.addDirected(to, from, courierType, minutes)
+	return nil
+}
+
+func (g *Graph) addDirected(from, to int64, courierType string, minutes int64) {
+	if g.edges[from] == nil {
+		g.edges[from] = make(map[int64]map[string]int64)
+	}
+	if g.edges[from][to] == nil {
+		g.edges[from][to] = make(map[string]int64)
+	}
+	g.edges[from][to][courierType] = minutes
+}
+
+func (g *Graph) IsEmpty() bool {
+	return len(g.edges) == 0
+}
+
+// Neighbors returns the direct connections of the region sorted by neighbour id.
+func (g *Graph) Neighbors(region int64) []Edge {
+	result := make([]Edge, 0, len(g.edges[region]))
+	for to, minutes := range g.edges[region] {
+		copied := make(map[string]int64, len(minutes))
+		for courierType, m := range minutes {
+			copied[courierType] = m
+		}
+		result = append(result, Edge{From: region, To: to, Minutes: copied})
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].To < result[b].To
+	})
+	return result
+}
+
+// TravelMinutes returns the shortest travel time between regions for the courier type,
+// the second result is false if the regions are not connected.
+func (g *Graph) TravelMinutes(from, to int64, courierType string) (int64, bool) {
+	if from == to {
+		return 0, true
+	}
+	minutes, ok := g.shortestPaths(from, courierType)[to]
+	return minutes, ok
+}
+
+func (g *Graph) shortestPaths(from int64, courierType string) map[int64]int64 {
+	g.cacheMutex.Lock()
+	defer g.cacheMutex.Unlock()
+
+	if g.cache[courierType] == nil {
+		g.cache[courierType] = make(map[int64]map[int64]int64)
+	}
+	if paths, ok := g.cache[courierType][from]; ok {
+		return paths
+	}
+
+	paths := map[int64]int64{from: 0}
+	queue := &distanceQueue{{region: from}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(distance)
+		if current.minutes > paths[current.region] {
+			continue
+		}
+		for next, minutes := range g.edges[current.region] {
+			m, ok := minutes[courierType]
+			if !ok {
+				continue
+			}
+			candidate := current.minutes + m
+			if known, ok := paths[next]; !ok || candidate < known {
+				paths[next] = candidate
+				heap.Push(queue, distance{region: next, minutes: candidate})
+			}
+		}
+	}
+
+	g.cache[courierType][from] = paths
+	return paths
+}
+
+type distance struct {
+	region  int64
+	minutes int64
+}
+
+type distanceQueue []distance
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].minutes < q[j].minutes }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(distance)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,73 @@
+package routing
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testGraph(t *testing.T) *Graph {
+	graph := NewGraph()
+	require.NoError(t, graph.AddEdge(1, 2, model.CourierTypeFoot, 15))
+	require.NoError(t, graph.AddEdge(2, 3, model.CourierTypeFoot, 10))
+	require.NoError(t, graph.AddEdge(1, 3, model.CourierTypeFoot, 40))
+	require.NoError(t, graph.AddEdge(1, 3, model.CourierTypeAuto, 5))
+	return graph
+}
+
+func TestGraphTravelMinutes(t *testing.T) {
+	graph := testGraph(t)
+
+	minutes, ok := graph.TravelMinutes(1, 3, model.CourierTypeFoot)
+	require.True(t, ok)
+	require.Equal(t, int64(25), minutes, "path through region 2 is shorter")
+
+	minutes, ok = graph.TravelMinutes(3, 1, model.CourierTypeAuto)
+	require.True(t, ok)
+	require.Equal(t, int64(5), minutes)
+
+	_, ok = graph.TravelMinutes(1, 2, model.CourierTypeAuto)
+	require.False(t, ok, "no AUTO edges to region 2")
+
+	neighbors := graph.Neighbors(1)
+	require.Len(t, neighbors, 2)
+	require.Equal(t, int64(2), neighbors[0].To)
+	require.Equal(t, map[string]int64{model.CourierTypeFoot: 40, model.CourierTypeAuto: 5}, neighbors[1].Minutes)
+}
+
+func TestLoadGraphFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.json")
+	content := `{"edges": [{"from": 1, "to": 2, "minutes": {"BIKE": 7}}]}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	graph, err := LoadGraphFile(path)
+	require.NoError(t, err)
+
+	minutes, ok := graph.TravelMinutes(2, 1, model.CourierTypeBike)
+	require.True(t, ok)
+	require.Equal(t, int64(7), minutes)
+}
+
+func TestEstimatorLegMinutes(t *testing.T) {
+	estimator := NewEstimator(testGraph(t))
+	first := int64(1)
+	second := int64(2)
+
+	minutes, ok := estimator.LegMinutes(model.CourierTypeFoot, nil, 1)
+	require.True(t, ok)
+	require.Equal(t, int64(25), minutes)
+
+	minutes, ok = estimator.LegMinutes(model.CourierTypeFoot, &first, 3)
+	require.True(t, ok)
+	require.Equal(t, int64(10+25), minutes)
+
+	_, ok = estimator.LegMinutes(model.CourierTypeAuto, &first, 2)
+	require.False(t, ok)
+
+	legacy := NewEstimator(NewGraph())
+	minutes, ok = legacy.LegMinutes(model.CourierTypeAuto, &second, 3)
+	require.True(t, ok)
+	require.Equal(t, int64(4), minutes, "empty graph keeps per-type minutes only")
+}
@@ -0,0 +1,177 @@
+package routing
+
+import (
+	"Ya.SumSchool23/time_interval"
+	"sort"
+)
+
+// Stop is an order to deliver in a region within one of its delivery windows.
+type Stop struct {
+	OrderId int64
+	Region  int64
+	Windows []time_interval.Interval
+}
+
+// PlannedStop is a stop with the planned delivery time in minutes since midnight.
+type PlannedStop struct {
+	Stop
+	Arrival  int
+	Lateness int
+}
+
+// Plan is a sequence of stops, it is feasible if every stop is delivered inside its window.
+type Plan struct {
+	Stops    []PlannedStop
+	Finish   int
+	Lateness int
+	Feasible bool
+}
+
+// Sequencer orders the stops of a group: a nearest-neighbour tour driven by delivery windows
+// and travel time, improved by 2-opt moves that keep or improve the window feasibility.
+type Sequencer struct {
+	estimator *Estimator
+}
+
+func NewSequencer(estimator *Estimator) *Sequencer {
+	return &Sequencer{
+		estimator: estimator,
+	}
+}
+
+// Sequence plans the delivery of the stops by a courier starting at the given minute of the day.
+// The second result is false if some stop cannot be reached over the region graph.
+func (s *Sequencer) Sequence(courierType string, start int, stops []Stop) (*Plan, bool) {
+	if len(stops) == 0 {
+		return &Plan{Finish: start, Feasible: true}, true
+	}
+
+	order, ok := s.nearestNeighbour(courierType, start, stops)
+	if !ok {
+		return nil, false
+	}
+	best, _ := s.simulate(courierType, start, order)
+
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				candidate := twoOptSwap(order, i, j)
+				plan, ok := s.simulate(courierType, start, candidate)
+				if ok && better(plan, best) {
+					order, best, improved = candidate, plan, true
+				}
+			}
+		}
+	}
+	return best, true
+}
+
+// nearestNeighbour always goes to the stop that can be delivered earliest,
+// ties are broken by the earliest window end.
+func (s *Sequencer) nearestNeighbour(courierType string, start int, stops []Stop) ([]Stop, bool) {
+	remaining := make([]Stop, len(stops))
+	copy(remaining, stops)
+	sort.SliceStable(remaining, func(a, b int) bool {
+		return remaining[a].OrderId < remaining[b].OrderId
+	})
+
+	result := make([]Stop, 0, len(stops))
+	now := start
+	var previous *int64
+	for len(remaining) > 0 {
+		bestIndex := -1
+		var bestArrival, bestDeadline int
+		for i, stop := range remaining {
+			leg, ok := s.estimator.LegMinutes(courierType, previous, stop.Region)
+			if !ok {
+				continue
+			}
+			arrival, _ := deliveryTime(now+int(leg), stop.Windows)
+			deadline := windowEnd(stop.Windows)
+			if bestIndex < 0 || arrival < bestArrival || (arrival == bestArrival && deadline < bestDeadline) {
+				bestIndex, bestArrival, bestDeadline = i, arrival, deadline
+			}
+		}
+		if bestIndex < 0 {
+			return nil, false
+		}
+
+		next := remaining[bestIndex]
+		result = append(result, next)
+		remaining = append(remaining[:bestIndex], remaining[bestIndex+1:]...)
+		now = bestArrival
+		region := next.Region
+		previous = &region
+	}
+	return result, true
+}
+
+func (s *Sequencer) simulate(courierType string, start int, stops []Stop) (*Plan, bool) {
+	plan := &Plan{Stops: make([]PlannedStop, len(stops)), Feasible: true}
+	now := start
+	var previous *int64
+	for i, stop := range stops {
+		leg, ok := s.estimator.LegMinutes(courierType, previous, stop.Region)
+		if !ok {
+			return nil, false
+		}
+		arrival, lateness := deliveryTime(now+int(leg), stop.Windows)
+		plan.Stops[i] = PlannedStop{Stop: stop, Arrival: arrival, Lateness: lateness}
+		plan.Lateness += lateness
+		if lateness > 0 {
+			plan.Feasible = false
+		}
+		now = arrival
+		region := stop.Region
+		previous = &region
+	}
+	plan.Finish = now
+	return plan, true
+}
+
+// deliveryTime returns when a stop reached at the given minute is delivered, waiting for the
+// next window if the courier is early, and how many minutes after the last window it is.
+func deliveryTime(reached int, windows []time_interval.Interval) (int, int) {
+	if len(windows) == 0 {
+		return reached, 0
+	}
+	for _, window := range time_interval.Merge(windows) {
+		if reached <= window.End {
+			if reached < window.Start {
+				return window.Start, 0
+			}
+			return reached, 0
+		}
+	}
+	return reached, reached - windowEnd(windows)
+}
+
+func windowEnd(windows []time_interval.Interval) int {
+	end := 24 * 60
+	if len(windows) > 0 {
+		end = 0
+		for _, window := range windows {
+			if window.End > end {
+				end = window.End
+			}
+		}
+	}
+	return end
+}
+
+func better(plan, than *Plan) bool {
+	if plan.Lateness != than.Lateness {
+		return plan.Lateness < than.Lateness
+	}
+	return plan.Finish < than.Finish
+}
+
+func twoOptSwap(stops []Stop, i, j int) []Stop {
+	result := make([]Stop, len(stops))
+	copy(result, stops)
+	for left, right := i, j; left < right; left, right = left+1, right-1 {
+		result[left], result[right] = result[right], result[left]
+	}
+	return result
+}
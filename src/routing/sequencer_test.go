@@ -0,0 +1,64 @@
+package routing
+
+import (
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/time_interval"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func windows(t *testing.T, strs ...string) []time_interval.Interval {
+	intervals, err := time_interval.ParseAll(strs)
+	require.NoError(t, err)
+	return intervals
+}
+
+func TestSequenceFollowsWindowsAndTravelTime(t *testing.T) {
+	graph := NewGraph()
+	require.NoError(t, graph.AddEdge(1, 2, model.CourierTypeBike, 5))
+	require.NoError(t, graph.AddEdge(2, 3, model.CourierTypeBike, 5))
+	require.NoError(t, graph.AddEdge(1, 3, model.CourierTypeBike, 30))
+	sequencer := NewSequencer(NewEstimator(graph))
+
+	stops := []Stop{
+		{OrderId: 1, Region: 3, Windows: windows(t, "10:00-12:00")},
+		{OrderId: 2, Region: 1, Windows: windows(t, "09:00-12:00")},
+		{OrderId: 3, Region: 2, Windows: windows(t, "09:00-12:00")},
+	}
+
+	plan, ok := sequencer.Sequence(model.CourierTypeBike, 9*60, stops)
+	require.True(t, ok)
+	require.True(t, plan.Feasible)
+
+	ids := make([]int64, len(plan.Stops))
+	for i, stop := range plan.Stops {
+		ids[i] = stop.OrderId
+	}
+	require.Equal(t, []int64{2, 3, 1}, ids)
+	require.Equal(t, 9*60+12, plan.Stops[0].Arrival)
+	require.Equal(t, 9*60+12+8+5, plan.Stops[1].Arrival)
+	require.Equal(t, 10*60, plan.Stops[2].Arrival, "courier waits for the window to open")
+}
+
+func TestSequenceReportsLateness(t *testing.T) {
+	sequencer := NewSequencer(NewEstimator(NewGraph()))
+
+	stops := []Stop{
+		{OrderId: 1, Region: 1, Windows: windows(t, "09:00-09:10")},
+		{OrderId: 2, Region: 1, Windows: windows(t, "09:00-09:20")},
+	}
+
+	plan, ok := sequencer.Sequence(model.CourierTypeFoot, 9*60, stops)
+	require.True(t, ok)
+	require.False(t, plan.Feasible)
+	require.Equal(t, 15+15, plan.Lateness)
+}
+
+func TestSequenceUnreachable(t *testing.T) {
+	graph := NewGraph()
+	require.NoError(t, graph.AddEdge(1, 2, model.CourierTypeFoot, 5))
+	sequencer := NewSequencer(NewEstimator(graph))
+
+	_, ok := sequencer.Sequence(model.CourierTypeFoot, 0, []Stop{{OrderId: 1, Region: 1}, {OrderId: 2, Region: 7}})
+	require.False(t, ok)
+}
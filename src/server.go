@@ -4,8 +4,13 @@ import (
 	"Ya.SumSchool23/controllers"
 	"Ya.SumSchool23/controllers/dto"
 	controller_errors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/outbox"
+	"Ya.SumSchool23/pricing"
 	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/routing"
 	"Ya.SumSchool23/services"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/webhooks"
 	"database/sql"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
@@ -17,6 +22,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 )
 
 func main() {
@@ -30,21 +36,92 @@ func main() {
 	//repository
 	courierRepository := repositories.NewCourierRepository(db)
 	orderRepository := repositories.NewOrderRepository(db)
+	scheduleRepository := repositories.NewScheduleRepository(db)
+	shiftRepository := repositories.NewShiftRepository(db)
+	regionRepository := repositories.NewRegionRepository(db)
+	assignmentRepository := repositories.NewAssignmentRepository(db)
+	tariffRepository := repositories.NewTariffRepository(db)
+	ledgerRepository := repositories.NewLedgerRepository(db)
+	auditRepository := repositories.NewAuditRepository(db)
+	outboxRepository := repositories.NewOutboxRepository(db)
+	webhookRepository := repositories.NewWebhookRepository(db)
 
 	//service
-	courierService := services.NewCourierService(courierRepository)
-	orderService := services.NewOrderService(orderRepository)
+	regionService := services.NewRegionService(regionRepository)
+	routingService := services.NewRoutingService(regionRepository, loadRegionGraph(regionRepository))
+	pricingRules := loadPricingRules()
+	courierService := services.NewCourierService(courierRepository, scheduleRepository, shiftRepository, orderRepository, assignmentRepository, ledgerRepository, regionService,
+		services.MetaInfoConfig{
+			RatingFromShifts: viper.GetBool("meta_info.rating_from_shifts"),
+			ThroughputWeight: viper.GetFloat64("meta_info.rating_weights.throughput"),
+			QualityWeight:    viper.GetFloat64("meta_info.rating_weights.quality"),
+			RatingScale:      viper.GetInt64("meta_info.rating_scale"),
+		})
+	assignmentService := services.NewAssignmentService(assignmentRepository, orderRepository, shiftRepository, courierService, regionService, routingService,
+		services.AssignmentConfig{
+			DefaultStrategy:     viper.GetString("assignment.strategy"),
+			DefaultFairness:     viper.GetString("assignment.fairness"),
+			FairnessHistoryDays: viper.GetInt("assignment.fairness_history_days"),
+			Pricing:             pricingRules,
+		})
+	replanner := services.NewReplanner(assignmentService, loadReplanConfig())
+	tariffService := services.NewTariffService(tariffRepository, regionService)
+	ledgerService := services.NewLedgerService(ledgerRepository, courierRepository, assignmentRepository,
+		services.LedgerConfig{
+			Pricing:             pricingRules,
+			LateDeliveryPenalty: viper.GetInt64("ledger.late_delivery_penalty"),
+		})
+	statementService := services.NewStatementService(courierService, orderRepository, ledgerRepository)
+	orderService := services.NewOrderService(orderRepository, regionService, tariffService, ledgerService, replanner)
+	auditService := services.NewAuditService(auditRepository, loadAuditRetentionConfig())
+	go auditService.RunRetention()
+	webhookService := services.NewWebhookService(webhookRepository, loadWebhookConfig())
+	go webhookService.RunDeliveries()
+	// webhooks get every event through a relay of their own, independent of the configured publisher
+	relayConfig := loadOutboxRelayConfig()
+	go services.NewOutboxRelay(outboxRepository, services.OutboxRelayWebhooks, webhookService, relayConfig).Run()
+	if publisher := loadOutboxPublisher(); publisher != nil {
+		go services.NewOutboxRelay(outboxRepository, services.OutboxRelayPublisher, publisher, relayConfig).Run()
+	}
+	eventStream := services.NewEventStream(outboxRepository, loadEventStreamConfig())
+	listener, err := repositories.ListenOutbox(getConnectionString(), eventStream.Wake)
+	if err != nil {
+		log.Fatalf("failed to listen to outbox events: %s", err.Error())
+	}
+	defer listener.Close()
+	go eventStream.RunPolling()
 
 	//controller
 	pingController := controllers.NewPingController()
 	courierController := controllers.NewCourierController(courierService)
 	orderController := controllers.NewOrderController(orderService)
+	scheduleController := controllers.NewScheduleController(courierService)
+	shiftController := controllers.NewShiftController(courierService)
+	regionController := controllers.NewRegionController(regionService, routingService)
+	assignmentController := controllers.NewAssignmentController(assignmentService, viper.GetString("assignment.admin_token"))
+	tariffController := controllers.NewTariffController(tariffService)
+	ledgerController := controllers.NewLedgerController(ledgerService)
+	statementController := controllers.NewStatementController(statementService)
+	auditController := controllers.NewAuditController(auditService)
+	webhookController := controllers.NewWebhookController(webhookService)
+	eventController := controllers.NewEventController(eventStream, viper.GetDuration("events.keep_alive"))
 
 	e := echo.New()
 	e.Validator = controllers.NewCustomValidator()
+	e.Use(controllers.Audit(auditService))
 	setupPingRoutes(pingController, e)
 	setupCourierRoutes(courierController, e)
 	setupOrdersRoutes(orderController, e)
+	setupScheduleRoutes(scheduleController, e)
+	setupShiftRoutes(shiftController, e)
+	setupRegionRoutes(regionController, e)
+	setupAssignmentRoutes(assignmentController, e)
+	setupTariffRoutes(tariffController, e)
+	setupLedgerRoutes(ledgerController, e)
+	setupStatementRoutes(statementController, e)
+	setupAuditRoutes(auditController, e)
+	setupWebhookRoutes(webhookController, e)
+	setupEventRoutes(eventController, e)
 
 	e.HTTPErrorHandler = customHTTPErrorHandler
 
@@ -89,9 +166,43 @@ func setupPingRoutes(c *controllers.PingController, e *echo.Echo) {
 
 func setupCourierRoutes(c *controllers.CourierController, e *echo.Echo) {
 	e.GET("/couriers", c.GetCouriers)
+	e.GET("/couriers/available", c.GetAvailableCouriers)
 	e.GET("/couriers/:courier_id", c.GetCourierById)
 	e.GET("/couriers/meta-info/:courier_id", c.GetCourierMetaById)
 	e.POST("/couriers", c.PostCouriers)
+	e.POST("/couriers/:courier_id/deactivate", c.PostCourierDeactivate)
+	e.POST("/couriers/:courier_id/activate", c.PostCourierActivate)
+}
+
+func setupScheduleRoutes(c *controllers.ScheduleController, e *echo.Echo) {
+	e.GET("/couriers/:courier_id/schedule", c.GetCourierSchedule)
+	e.PUT("/couriers/:courier_id/schedule", c.PutCourierSchedule)
+	e.POST("/couriers/:courier_id/schedule/overrides", c.PostScheduleOverride)
+	e.DELETE("/couriers/:courier_id/schedule/overrides/:override_id", c.DeleteScheduleOverride)
+	e.GET("/couriers/:courier_id/calendar", c.GetCourierCalendar)
+}
+
+func setupShiftRoutes(c *controllers.ShiftController, e *echo.Echo) {
+	e.POST("/couriers/:courier_id/shifts/start", c.PostShiftStart)
+	e.POST("/couriers/:courier_id/shifts/end", c.PostShiftEnd)
+	e.GET("/couriers/:courier_id/shifts", c.GetShifts)
+}
+
+func setupRegionRoutes(c *controllers.RegionController, e *echo.Echo) {
+	e.GET("/regions", c.GetRegions)
+	e.GET("/regions.geojson", c.GetRegionsGeoJson)
+	e.GET("/regions/:region_id", c.GetRegionById)
+	e.GET("/regions/:region_id/neighbors", c.GetRegionNeighbors)
+	e.POST("/regions", c.PostRegion)
+	e.PUT("/regions/:region_id", c.PutRegion)
+	e.DELETE("/regions/:region_id", c.DeleteRegion)
+}
+
+func setupAssignmentRoutes(c *controllers.AssignmentController, e *echo.Echo) {
+	e.POST("/orders/assign", c.PostOrdersAssign)
+	e.GET("/couriers/assignments", c.GetCouriersAssignments)
+	e.GET("/orders/:order_id/assignment-explanation", c.GetAssignmentExplanation)
+	e.POST("/orders/:order_id/assign-to/:courier_id", c.PostOrderAssignTo)
 }
 
 func setupOrdersRoutes(c *controllers.OrderController, e *echo.Echo) {
@@ -99,7 +210,190 @@ func setupOrdersRoutes(c *controllers.OrderController, e *echo.Echo) {
 	e.GET("/orders/:order_id", c.GetOrderById)
 	e.POST("/orders", c.PostOrders)
 	e.POST("/orders/complete", c.PostOrdersComplete)
+	e.GET("/orders/late-rates", c.GetOrdersLateRates)
+	e.POST("/orders/:order_id/feedback", c.PostOrderFeedback)
+	e.GET("/orders/:order_id/feedback", c.GetOrderFeedback)
+	e.GET("/orders/:order_id/history", c.GetOrderHistory)
+
+}
+
+func setupTariffRoutes(c *controllers.TariffController, e *echo.Echo) {
+	e.GET("/tariffs", c.GetTariffs)
+	e.GET("/tariffs/current", c.GetCurrentTariff)
+	e.GET("/tariffs/:version", c.GetTariffByVersion)
+	e.POST("/tariffs", c.PostTariff)
+}
+
+func setupLedgerRoutes(c *controllers.LedgerController, e *echo.Echo) {
+	e.GET("/couriers/:courier_id/ledger", c.GetCourierLedger)
+	e.POST("/couriers/:courier_id/ledger", c.PostCourierLedgerEntry)
+	e.POST("/payouts/close-period", c.PostClosePeriod)
+	e.GET("/payouts/periods/:period_id", c.GetPayoutPeriod)
+}
+
+func setupStatementRoutes(c *controllers.StatementController, e *echo.Echo) {
+	e.GET("/couriers/:courier_id/statement", c.GetCourierStatement)
+}
+
+func setupAuditRoutes(c *controllers.AuditController, e *echo.Echo) {
+	e.GET("/audit", c.GetAudit)
+}
+
+func setupWebhookRoutes(c *controllers.WebhookController, e *echo.Echo) {
+	e.GET("/webhooks", c.GetWebhooks)
+	e.GET("/webhooks/:webhook_id", c.GetWebhookById)
+	e.POST("/webhooks", c.PostWebhook)
+	e.PUT("/webhooks/:webhook_id", c.PutWebhook)
+	e.DELETE("/webhooks/:webhook_id", c.DeleteWebhook)
+	e.GET("/webhooks/:webhook_id/deliveries", c.GetWebhookDeliveries)
+}
+
+func setupEventRoutes(c *controllers.EventController, e *echo.Echo) {
+	e.GET("/events/stream", c.GetEventStream)
+	e.GET("/ws", c.GetWebSocket)
+}
+
+// loadPricingRules reads the group discount of every courier type, the default discount is used when none is set.
+func loadPricingRules() pricing.Rules {
+	rules := make(pricing.Rules)
+	for _, courierType := range []string{model.CourierTypeFoot, model.CourierTypeBike, model.CourierTypeAuto} {
+		key := "pricing.next_order_discount." + strings.ToLower(courierType)
+		discount := int64(pricing.DefaultNextOrderDiscount)
+		if viper.IsSet(key) {
+			discount = viper.GetInt64(key)
+		}
+		rules[courierType] = pricing.Rule{NextOrderDiscount: discount}
+	}
+	if err := rules.Validate(); err != nil {
+		log.Fatalf("invalid pricing config: %v", err)
+	}
+	return rules
+}
+
+func loadReplanConfig() services.ReplanConfig {
+	config := services.ReplanConfig{
+		Trigger:  viper.GetString("assignment.replan.trigger"),
+		Debounce: viper.GetDuration("assignment.replan.debounce"),
+	}
+	switch config.Trigger {
+	case "":
+		config.Trigger = services.ReplanTriggerOff
+	case services.ReplanTriggerOff, services.ReplanTriggerBatch:
+	case services.ReplanTriggerDebounce:
+		if config.Debounce <= 0 {
+			log.Fatal("assignment.replan.debounce must be positive for the debounce trigger")
+		}
+	default:
+		log.Fatalf("unknown assignment.replan.trigger '%s'", config.Trigger)
+	}
+	return config
+}
+
+func loadAuditRetentionConfig() services.AuditRetentionConfig {
+	config := services.AuditRetentionConfig{
+		Days:      viper.GetInt("audit.retention.days"),
+		ExportDir: viper.GetString("audit.retention.export_dir"),
+		Interval:  viper.GetDuration("audit.retention.interval"),
+	}
+	if config.Days < 0 {
+		log.Fatal("audit.retention.days must not be negative")
+	}
+	if config.Days > 0 && (config.ExportDir == "" || config.Interval <= 0) {
+		log.Fatal("audit.retention.export_dir and a positive audit.retention.interval must be set to export audit entries")
+	}
+	return config
+}
+
+func loadOutboxRelayConfig() services.OutboxRelayConfig {
+	config := services.OutboxRelayConfig{
+		Interval:    viper.GetDuration("outbox.interval"),
+		BatchSize:   viper.GetInt("outbox.batch_size"),
+		Lease:       viper.GetDuration("outbox.lease"),
+		BaseBackoff: viper.GetDuration("outbox.base_backoff"),
+		MaxBackoff:  viper.GetDuration("outbox.max_backoff"),
+	}
+	if config.Interval <= 0 || config.BatchSize <= 0 || config.Lease <= 0 || config.BaseBackoff <= 0 {
+		log.Fatal("outbox.interval, outbox.batch_size, outbox.lease and outbox.base_backoff must be positive")
+	}
+	if config.MaxBackoff < config.BaseBackoff {
+		log.Fatal("outbox.max_backoff must not be less than outbox.base_backoff")
+	}
+	return config
+}
+
+// loadOutboxPublisher returns the publisher of outbox.publisher, nil if it is off.
+func loadOutboxPublisher() outbox.Publisher {
+	switch publisher := viper.GetString("outbox.publisher"); publisher {
+	case "", "off":
+		return nil
+	case "file":
+		if viper.GetString("outbox.file") == "" {
+			log.Fatal("outbox.file must be set for the file publisher")
+		}
+		return outbox.NewFilePublisher(viper.GetString("outbox.file"))
+	case "webhook":
+		if viper.GetString("outbox.webhook.url") == "" {
+			log.Fatal("outbox.webhook.url must be set for the webhook publisher")
+		}
+		return outbox.NewWebhookPublisher(viper.GetString("outbox.webhook.url"), viper.GetDuration("outbox.webhook.timeout"))
+	case "memory":
+		return outbox.NewMemoryPublisher()
+	default:
+		log.Fatalf("unknown outbox.publisher '%s'", publisher)
+	}
+	return nil
+}
 
+func loadWebhookConfig() services.WebhookConfig {
+	config := services.WebhookConfig{
+		Retry: webhooks.RetryPolicy{
+			MaxAttempts: viper.GetInt("webhooks.max_attempts"),
+			BaseBackoff: viper.GetDuration("webhooks.base_backoff"),
+			MaxBackoff:  viper.GetDuration("webhooks.max_backoff"),
+		},
+		Timeout:   viper.GetDuration("webhooks.timeout"),
+		Interval:  viper.GetDuration("webhooks.interval"),
+		BatchSize: viper.GetInt("webhooks.batch_size"),
+	}
+	if config.Retry.MaxAttempts <= 0 || config.Retry.BaseBackoff <= 0 || config.Retry.MaxBackoff < config.Retry.BaseBackoff {
+		log.Fatal("webhooks.max_attempts and webhooks.base_backoff must be positive and webhooks.max_backoff at least the base backoff")
+	}
+	if config.Timeout <= 0 || config.Interval <= 0 || config.BatchSize <= 0 {
+		log.Fatal("webhooks.timeout, webhooks.interval and webhooks.batch_size must be positive")
+	}
+	return config
+}
+
+func loadEventStreamConfig() services.EventStreamConfig {
+	config := services.EventStreamConfig{
+		PollInterval: viper.GetDuration("events.poll_interval"),
+		BatchSize:    viper.GetInt("events.batch_size"),
+	}
+	if config.PollInterval <= 0 || config.BatchSize <= 0 || viper.GetDuration("events.keep_alive") <= 0 {
+		log.Fatal("events.poll_interval, events.batch_size and events.keep_alive must be positive")
+	}
+	return config
+}
+
+// loadRegionGraph reads the region graph from routing.graph_file if it is set and from the region_edges table otherwise.
+func loadRegionGraph(r *repositories.RegionRepository) *routing.Graph {
+	if graphFile := viper.GetString("routing.graph_file"); graphFile != "" {
+		graph, err := routing.LoadGraphFile(graphFile)
+		if err != nil {
+			log.Fatalf("failed to load region graph: %s", err.Error())
+		}
+		return graph
+	}
+
+	edges, err := r.GetRegionEdges()
+	if err != nil {
+		log.Fatalf("failed to load region edges: %s", err.Error())
+	}
+	graph, err := services.BuildRegionGraph(edges)
+	if err != nil {
+		log.Fatalf("failed to build region graph: %s", err.Error())
+	}
+	return graph
 }
 
 func initDb(connStr string) *sql.DB {
@@ -142,6 +436,8 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 		_ = c.JSON(http.StatusTooManyRequests, dto.EmptyResponse{})
 	case controller_errors.NotImplemented:
 		_ = c.JSON(http.StatusNotImplemented, dto.EmptyResponse{})
+	case controller_errors.Forbidden:
+		_ = c.JSON(http.StatusForbidden, dto.EmptyResponse{})
 	default:
 		code := http.StatusInternalServerError
 		if he, ok := err.(*echo.HTTPError); ok {
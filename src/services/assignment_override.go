@@ -0,0 +1,191 @@
+package services
+
+import (
+	"Ya.SumSchool23/assignment"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"Ya.SumSchool23/time_interval"
+	"context"
+	"time"
+)
+
+// StrategyManual is the strategy of group orders created by manual assignment.
+const StrategyManual = "manual"
+
+// AssignToCourier moves the order into a group order of the courier on the date and pins it there.
+// The order must fit the courier's capacity and working hours unless force is set, a forced order
+// gets a group of its own after the courier's last group. Every override is recorded.
+func (s *AssignmentService) AssignToCourier(
+	ctx context.Context,
+	orderId, courierId int64,
+	date time.Time,
+	force bool,
+) (*model.AssignmentOverride, error) {
+	s.planMutex.Lock()
+	defer s.planMutex.Unlock()
+
+	order, err := s.orderRepository.GetOrderById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.CompletedTime != nil {
+		return nil, cerrors.BadRequest.Newf("order with id = '%v' is already completed", orderId)
+	}
+	if !order.DeliveryDate.Equal(date) {
+		return nil, cerrors.BadRequest.Newf("order with id = '%v' is for '%s', not for '%s'",
+			orderId, order.DeliveryDate.Format(dateLayout), date.Format(dateLayout))
+	}
+	courier, err := s.courierService.GetCourierById(courierId)
+	if err != nil {
+		return nil, err
+	}
+	if !courier.Active {
+		return nil, cerrors.BadRequest.Newf("courier with id = '%v' is not active", courierId)
+	}
+
+	windows, err := time_interval.ParseAll(order.DeliveryHours)
+	if err != nil && !force {
+		return nil, cerrors.BadRequest.Wrapf(err, "invalid delivery hours of order '%v'", orderId)
+	}
+	workingHours, err := s.courierService.GetWorkingHours([]*model.Courier{courier}, date)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := service_data.NewAssignmentPlanData{
+		Date:           date,
+		Strategy:       StrategyManual,
+		PinnedOrderIds: []int64{orderId},
+		Actor:          actorOf(ctx),
+	}
+	override := &model.AssignmentOverride{OrderId: orderId, CourierId: courierId, Date: date, Forced: force}
+
+	// take the order out of its current group order
+	var source *model.GroupOrder
+	if order.GroupOrderId != nil {
+		if source, err = s.assignmentRepository.GetGroupOrderById(*order.GroupOrderId); err != nil {
+			return nil, err
+		}
+		override.PreviousCourierId = &source.CourierId
+		plan.ReleasedOrderIds = []int64{orderId}
+		source.Orders = withoutOrder(source.Orders, orderId)
+		if len(source.Orders) == 0 {
+			plan.ReleasedGroupIds = []int64{source.GroupOrderId}
+		} else {
+			plan.Groups = append(plan.Groups, toGroupOrderData(source))
+		}
+	}
+
+	stored, err := s.assignmentRepository.GetGroupOrders(date, &courierId)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*assignment.Group, 0, len(stored))
+	for _, group := range stored {
+		if source != nil && group.GroupOrderId == source.GroupOrderId {
+			if group = source; len(group.Orders) == 0 {
+				continue
+			}
+		}
+		converted, err := toAssignmentGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		converted.Fixed = group.Started()
+		groups = append(groups, converted)
+	}
+
+	target := assignment.Courier{
+		CourierId:    courier.CourierId,
+		CourierType:  courier.CourierType,
+		Regions:      courier.Regions,
+		WorkingHours: workingHours[courier.CourierId],
+	}
+	candidate := toAssignmentOrder(order, windows)
+	group, ok := s.planner.Insert(target, groups, candidate)
+	if !ok {
+		if !force {
+			return nil, cerrors.BadRequest.Newf("order with id = '%v' cannot be assigned to courier with id = '%v': %s",
+				orderId, courierId, assignment.MissReason(target, candidate))
+		}
+		if group, err = forcedGroup(target, groups, candidate); err != nil {
+			return nil, err
+		}
+	}
+
+	data := service_data.NewGroupOrderData{
+		GroupOrderId: group.GroupOrderId,
+		CourierId:    courierId,
+		StartMinute:  group.Start,
+		FinishMinute: group.Finish,
+		Stops:        make([]service_data.NewGroupOrderStopData, len(group.Orders)),
+	}
+	for i, o := range group.Orders {
+		data.Stops[i].OrderId = o.OrderId
+		data.Stops[i].PlannedArrival = formatClock(group.Arrivals[i])
+	}
+	plan.Groups = append(plan.Groups, data)
+
+	id, err := s.assignmentRepository.SaveManualAssignment(plan, override)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.recordReassignments(ctx, []*model.Order{order}); err != nil {
+		return nil, err
+	}
+	return s.assignmentRepository.GetOverrideById(id)
+}
+
+// forcedGroup plans a group with the single order right after the last group of the courier,
+// or at the start of the first working interval if the courier has no groups. The group must end within the day.
+func forcedGroup(courier assignment.Courier, groups []*assignment.Group, order assignment.Order) (*assignment.Group, error) {
+	start := 0
+	if len(courier.WorkingHours) > 0 {
+		start = courier.WorkingHours[0].Start
+	}
+	for _, group := range groups {
+		if group.Finish > start {
+			start = group.Finish
+		}
+	}
+	arrival := start + model.CourierTypeProfiles[courier.CourierType].FirstOrderMinutes
+	if arrival >= 24*60 {
+		return nil, cerrors.BadRequest.Newf("order with id = '%v' cannot be forced to courier with id = '%v': "+
+			"its group would end after midnight", order.OrderId, courier.CourierId)
+	}
+	return &assignment.Group{
+		Orders:   []assignment.Order{order},
+		Arrivals: []int{arrival},
+		Start:    start,
+		Finish:   arrival,
+	}, nil
+}
+
+func withoutOrder(orders []*model.Order, orderId int64) []*model.Order {
+	result := make([]*model.Order, 0, len(orders))
+	for _, order := range orders {
+		if order.OrderId != orderId {
+			result = append(result, order)
+		}
+	}
+	return result
+}
+
+// toGroupOrderData keeps the stored timings of the group and its orders.
+func toGroupOrderData(group *model.GroupOrder) service_data.NewGroupOrderData {
+	data := service_data.NewGroupOrderData{
+		GroupOrderId: group.GroupOrderId,
+		CourierId:    group.CourierId,
+		StartMinute:  group.StartMinute,
+		FinishMinute: group.FinishMinute,
+		Stops:        make([]service_data.NewGroupOrderStopData, len(group.Orders)),
+	}
+	for i, order := range group.Orders {
+		data.Stops[i].OrderId = order.OrderId
+		if order.PlannedArrival != nil {
+			data.Stops[i].PlannedArrival = *order.PlannedArrival
+		}
+	}
+	return data
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"Ya.SumSchool23/assignment"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestForcedGroupEndsWithinTheDay(t *testing.T) {
+	courier := assignment.Courier{CourierId: 1, CourierType: model.CourierTypeFoot}
+	order := assignment.Order{OrderId: 2}
+	first := model.CourierTypeProfiles[model.CourierTypeFoot].FirstOrderMinutes
+
+	group, err := forcedGroup(courier, []*assignment.Group{{Start: 20 * 60, Finish: 22 * 60}}, order)
+	require.NoError(t, err)
+	require.Equal(t, 22*60, group.Start)
+	require.Equal(t, []int{22*60 + first}, group.Arrivals)
+	_, err = parseClock(formatClock(group.Finish))
+	require.NoError(t, err, "a stored group must be loaded back")
+
+	_, err = forcedGroup(courier, []*assignment.Group{{Start: 22 * 60, Finish: 24*60 - first}}, order)
+	require.Equal(t, cerrors.BadRequest, cerrors.GetType(err), "the arrival would be 24:00")
+}
@@ -0,0 +1,664 @@
+package services
+
+import (
+	"Ya.SumSchool23/assignment"
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"Ya.SumSchool23/time_interval"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const clockLayout = "15:04"
+
+// ReasonInvalidDeliveryHours is the rejection reason of orders not planned because of malformed delivery hours.
+const ReasonInvalidDeliveryHours = "invalid_delivery_hours"
+
+// FairnessOff turns off the configured fairness objective for a run.
+const FairnessOff = "none"
+
+// AssignmentConfig configures order assignment.
+type AssignmentConfig struct {
+	// DefaultStrategy is used when the request does not choose a strategy.
+	DefaultStrategy string
+	// DefaultFairness is the fairness objective used when the request does not choose one, empty for none.
+	DefaultFairness string
+	// FairnessHistoryDays is the number of days before the assignment date the historical earnings are taken from.
+	FairnessHistoryDays int
+	// Pricing gives the effective cost of orders in group orders the plans are scored by.
+	Pricing pricing.Rules
+}
+
+type AssignmentService struct {
+	assignmentRepository *repositories.AssignmentRepository
+	orderRepository      *repositories.OrderRepository
+	shiftRepository      *repositories.ShiftRepository
+	courierService       *CourierService
+	regionService        *RegionService
+	planner              assignment.Planner
+	config               AssignmentConfig
+	// planMutex serializes changes of the stored plan
+	planMutex sync.Mutex
+}
+
+func NewAssignmentService(
+	r *repositories.AssignmentRepository,
+	or *repositories.OrderRepository,
+	shr *repositories.ShiftRepository,
+	cs *CourierService,
+	rs *RegionService,
+	rts *RoutingService,
+	config AssignmentConfig,
+) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepository: r,
+		orderRepository:      or,
+		shiftRepository:      shr,
+		courierService:       cs,
+		regionService:        rs,
+		planner:              assignment.NewPlanner(rts.Sequencer()),
+		config:               config,
+	}
+}
+
+// assignmentInput is the problem of an assignment run together with what must be changed in storage
+// to apply its result.
+type assignmentInput struct {
+	problem assignment.Problem
+	// orders are all orders of the problem by id, fixed groups included
+	orders map[int64]*model.Order
+	// released are ids of unstarted group orders of the date, their orders are planned again
+	released []int64
+	// releasedOrders are unpinned orders taken out of groups kept for pinned orders
+	releasedOrders []int64
+	// skipped are orders that cannot be planned at all, e.g. with malformed delivery hours
+	skipped []*model.Order
+}
+
+// Assign plans the open orders between couriers available on the date with the strategy
+// (the configured one if empty) and stores the plan. Group orders a courier has already started are kept,
+// the others are planned again together with the orders not assigned yet.
+// An incremental run keeps all existing group orders and only adds the orders not assigned yet,
+// to unstarted groups or to new ones.
+func (s *AssignmentService) Assign(ctx context.Context, data service_data.NewAssignData) (*service_data.NewOrderAssignResponseData, error) {
+	s.planMutex.Lock()
+	defer s.planMutex.Unlock()
+
+	assigner, input, result, err := s.run(data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []service_data.NewGroupOrderData
+	for _, plan := range result.Plans {
+		for _, group := range plan.Groups {
+			if group.Fixed {
+				continue
+			}
+			planned := service_data.NewGroupOrderData{
+				GroupOrderId: group.GroupOrderId,
+				CourierId:    plan.Courier.CourierId,
+				StartMinute:  group.Start,
+				FinishMinute: group.Finish,
+				Stops:        make([]service_data.NewGroupOrderStopData, len(group.Orders)),
+			}
+			for i, order := range group.Orders {
+				planned.Stops[i].OrderId = order.OrderId
+				planned.Stops[i].PlannedArrival = formatClock(group.Arrivals[i])
+			}
+			groups = append(groups, planned)
+		}
+	}
+	rejections := make([]*model.AssignmentRejection, 0, len(result.Rejections)+len(input.skipped))
+	for _, rejection := range result.Rejections {
+		stored := &model.AssignmentRejection{
+			OrderId:       rejection.OrderId,
+			Reason:        rejection.Reason,
+			NearestMisses: make([]model.NearestMiss, len(rejection.NearestMisses)),
+		}
+		for i, miss := range rejection.NearestMisses {
+			stored.NearestMisses[i] = model.NearestMiss{CourierId: miss.CourierId, Reason: miss.Reason}
+		}
+		rejections = append(rejections, stored)
+	}
+	for _, order := range input.skipped {
+		rejections = append(rejections, &model.AssignmentRejection{OrderId: order.OrderId, Reason: ReasonInvalidDeliveryHours})
+	}
+
+	// every order the plan can move, as it is before
+	orderIds := append([]int64{}, input.releasedOrders...)
+	for _, group := range groups {
+		for _, stop := range group.Stops {
+			orderIds = append(orderIds, stop.OrderId)
+		}
+	}
+	for _, rejection := range rejections {
+		orderIds = append(orderIds, rejection.OrderId)
+	}
+	before, err := s.auditedOrders(ctx, orderIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.assignmentRepository.SavePlan(service_data.NewAssignmentPlanData{
+		Date:             data.Date,
+		Strategy:         assigner.Name(),
+		ReleasedGroupIds: input.released,
+		ReleasedOrderIds: input.releasedOrders,
+		Groups:           groups,
+		Rejections:       rejections,
+		Actor:            data.Actor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = s.recordReassignments(ctx, before); err != nil {
+		return nil, err
+	}
+
+	response, err := s.GetAssignments(data.Date, nil)
+	if err != nil {
+		return nil, err
+	}
+	response.Strategy = assigner.Name()
+	response.Metrics = toMetricsData(assignment.Evaluate(result), len(input.skipped))
+	return response, nil
+}
+
+// auditedOrders loads the orders to record their reassignments, there is nothing to load outside of an audited request.
+func (s *AssignmentService) auditedOrders(ctx context.Context, orderIds []int64) ([]*model.Order, error) {
+	if auditScopeOf(ctx) == nil || len(orderIds) == 0 {
+		return nil, nil
+	}
+	return s.orderRepository.GetOrdersByIds(orderIds)
+}
+
+// recordReassignments records every order that was moved to another group order or out of its group,
+// before holds the orders as they were.
+func (s *AssignmentService) recordReassignments(ctx context.Context, before []*model.Order) error {
+	if len(before) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(before))
+	byId := make(map[int64]*model.Order, len(before))
+	for i, order := range before {
+		ids[i] = order.OrderId
+		byId[order.OrderId] = order
+	}
+	after, err := s.orderRepository.GetOrdersByIds(ids)
+	if err != nil {
+		return err
+	}
+	for _, order := range after {
+		previous := byId[order.OrderId]
+		if !sameGroupOrder(previous.GroupOrderId, order.GroupOrderId) {
+			recordChange(ctx, model.AuditEntityOrder, order.OrderId, previous, order)
+		}
+	}
+	return nil
+}
+
+func sameGroupOrder(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// PreviewAssign computes the plan Assign would store without storing anything.
+// Hypothetical couriers and orders of whatIf take part in the run with negative ids,
+// groups not stored yet have id 0.
+func (s *AssignmentService) PreviewAssign(data service_data.NewAssignData, whatIf *service_data.NewWhatIfData) (*service_data.NewOrderAssignResponseData, error) {
+	assigner, input, result, err := s.run(data, whatIf)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &service_data.NewOrderAssignResponseData{
+		Date:     data.Date.Format(dateLayout),
+		Couriers: make([]service_data.NewCouriersGroupOrdersData, 0),
+		Strategy: assigner.Name(),
+		Metrics:  toMetricsData(assignment.Evaluate(result), len(input.skipped)),
+	}
+	for _, plan := range result.Plans {
+		if len(plan.Groups) == 0 {
+			continue
+		}
+		courier := service_data.NewCouriersGroupOrdersData{
+			CourierId: plan.Courier.CourierId,
+			Orders:    make([]service_data.NewGroupOrdersData, len(plan.Groups)),
+		}
+		for i, group := range plan.Groups {
+			courier.Orders[i] = service_data.NewGroupOrdersData{
+				GroupOrderId: group.GroupOrderId,
+				Orders:       make([]service_data.NewOrderDtoData, len(group.Orders)),
+			}
+			for j, order := range group.Orders {
+				arrival := formatClock(group.Arrivals[j])
+				courier.Orders[i].Orders[j] = toOrderDtoData(input.orders[order.OrderId])
+				courier.Orders[i].Orders[j].PlannedArrival = &arrival
+			}
+		}
+		response.Couriers = append(response.Couriers, courier)
+	}
+	return response, nil
+}
+
+func (s *AssignmentService) run(data service_data.NewAssignData, whatIf *service_data.NewWhatIfData) (assignment.Assigner, *assignmentInput, *assignment.Result, error) {
+	assigner, err := s.assigner(data.Strategy)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fairness := data.Fairness
+	if fairness == "" {
+		fairness = s.config.DefaultFairness
+	}
+	if fairness == FairnessOff {
+		fairness = assignment.FairnessNone
+	}
+	if err = assignment.ValidateFairness(fairness); err != nil {
+		return nil, nil, nil, cerrors.BadRequest.Wrap(err, "invalid fairness objective")
+	}
+
+	input, err := s.loadInput(data.Date, data.Incremental, fairness)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if whatIf != nil {
+		if err = s.addHypothetical(input, whatIf); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return assigner, input, assigner.Assign(input.problem), nil
+}
+
+// GetAssignments returns the stored group orders of the date, optionally only of one courier.
+func (s *AssignmentService) GetAssignments(date time.Time, courierId *int64) (*service_data.NewOrderAssignResponseData, error) {
+	if courierId != nil {
+		if _, err := s.courierService.GetCourierById(*courierId); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := s.assignmentRepository.GetGroupOrders(date, courierId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &service_data.NewOrderAssignResponseData{
+		Date:     date.Format(dateLayout),
+		Couriers: make([]service_data.NewCouriersGroupOrdersData, 0),
+	}
+	for _, group := range groups {
+		last := len(response.Couriers) - 1
+		if last < 0 || response.Couriers[last].CourierId != group.CourierId {
+			response.Couriers = append(response.Couriers, service_data.NewCouriersGroupOrdersData{
+				CourierId: group.CourierId,
+				Orders:    make([]service_data.NewGroupOrdersData, 0),
+			})
+			last++
+		}
+		response.Couriers[last].Orders = append(response.Couriers[last].Orders, toGroupOrdersData(group))
+	}
+	return response, nil
+}
+
+// GetAssignmentExplanation returns the order and, if it is not assigned, why the last assignment run left it out.
+func (s *AssignmentService) GetAssignmentExplanation(orderId int64) (*model.Order, *model.AssignmentRejection, error) {
+	order, err := s.orderRepository.GetOrderById(orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.GroupOrderId != nil {
+		return order, nil, nil
+	}
+
+	rejection, err := s.assignmentRepository.GetRejection(orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rejection == nil {
+		return nil, nil, cerrors.NotFound.Newf("order with id = '%v' has not taken part in an assignment", orderId)
+	}
+	return order, rejection, nil
+}
+
+func (s *AssignmentService) assigner(strategy string) (assignment.Assigner, error) {
+	if strategy == "" {
+		strategy = s.config.DefaultStrategy
+	}
+	assigner, err := assignment.NewAssigner(strategy, s.planner)
+	if err != nil {
+		return nil, cerrors.BadRequest.Wrap(err, "invalid assignment strategy")
+	}
+	return assigner, nil
+}
+
+// loadInput collects the couriers available on the date with their working hours and the orders to plan.
+// Only couriers checked in right now take part in the assignment for today.
+// For an incremental run unstarted group orders are kept and can take more orders.
+// The earnings fairness objective compares couriers by their average daily earnings of the history period.
+func (s *AssignmentService) loadInput(date time.Time, incremental bool, fairness string) (*assignmentInput, error) {
+	couriers, err := s.courierService.GetAvailableCouriers(nil, &date, math.MaxInt64, 0)
+	if err != nil {
+		return nil, err
+	}
+	if date.Equal(today()) {
+		if couriers, err = s.checkedIn(couriers); err != nil {
+			return nil, err
+		}
+	}
+
+	workingHours, err := s.courierService.GetWorkingHours(couriers, date)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &assignmentInput{
+		problem: assignment.Problem{
+			Couriers: make([]assignment.Courier, len(couriers)),
+			Fixed:    make(map[int64][]*assignment.Group),
+			Pinned:   make(map[int64][]*assignment.Group),
+			Fairness: fairness,
+			Pricing:  s.config.Pricing,
+		},
+		orders: make(map[int64]*model.Order),
+	}
+
+	var earnings map[int64]int64
+	historyDays := s.config.FairnessHistoryDays
+	if fairness == assignment.FairnessEarnings && historyDays > 0 {
+		if earnings, err = s.courierService.GetEarnings(couriers, date.AddDate(0, 0, -historyDays), date); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, courier := range couriers {
+		input.problem.Couriers[i] = assignment.Courier{
+			CourierId:    courier.CourierId,
+			CourierType:  courier.CourierType,
+			Regions:      courier.Regions,
+			WorkingHours: workingHours[courier.CourierId],
+			Baseline:     averageDaily(earnings[courier.CourierId], historyDays),
+		}
+	}
+
+	orders, err := s.orderRepository.GetUnassignedOrders(date)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.assignmentRepository.GetGroupOrders(date, nil)
+	if err != nil {
+		return nil, err
+	}
+	courierTypes := make(map[int64]string, len(couriers))
+	for _, courier := range couriers {
+		courierTypes[courier.CourierId] = courier.CourierType
+	}
+	for _, group := range groups {
+		if group.Started() {
+			fixed, err := toAssignmentGroup(group)
+			if err != nil {
+				return nil, err
+			}
+			for _, order := range group.Orders {
+				input.orders[order.OrderId] = order
+			}
+			input.problem.Fixed[group.CourierId] = append(input.problem.Fixed[group.CourierId], fixed)
+			continue
+		}
+
+		if incremental {
+			kept, err := toAssignmentGroup(group)
+			if err != nil {
+				return nil, err
+			}
+			for _, order := range group.Orders {
+				input.orders[order.OrderId] = order
+			}
+			input.problem.Pinned[group.CourierId] = append(input.problem.Pinned[group.CourierId], kept)
+			continue
+		}
+
+		pinned, unpinned := splitPinned(group.Orders)
+		if len(pinned) == 0 {
+			input.released = append(input.released, group.GroupOrderId)
+			orders = append(orders, group.Orders...)
+			continue
+		}
+
+		// the group stays with the courier for its pinned orders, the others are planned again
+		for _, order := range unpinned {
+			input.releasedOrders = append(input.releasedOrders, order.OrderId)
+		}
+		orders = append(orders, unpinned...)
+		kept, err := s.pinnedGroup(group, pinned, courierTypes[group.CourierId])
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range pinned {
+			input.orders[order.OrderId] = order
+		}
+		input.problem.Pinned[group.CourierId] = append(input.problem.Pinned[group.CourierId], kept)
+	}
+
+	regions := make([]int64, len(orders))
+	for i, order := range orders {
+		regions[i] = order.Regions
+	}
+	locations, err := s.regionService.GetLocations(regions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		windows, err := time_interval.ParseAll(order.DeliveryHours)
+		if err != nil {
+			input.skipped = append(input.skipped, order)
+			continue
+		}
+		input.orders[order.OrderId] = order
+		converted := toAssignmentOrder(order, windows)
+		if order.PromisedBy != nil {
+			location, ok := locations[order.Regions]
+			if !ok {
+				location = time.UTC
+			}
+			converted.PromisedBy = promisedMinute(*order.PromisedBy, date, location)
+		}
+		input.problem.Orders = append(input.problem.Orders, converted)
+	}
+	return input, nil
+}
+
+// addHypothetical adds the what-if couriers and orders to the problem with ids -1, -2, ...
+func (s *AssignmentService) addHypothetical(input *assignmentInput, whatIf *service_data.NewWhatIfData) error {
+	for i, data := range whatIf.Couriers {
+		if err := s.regionService.ValidateRegions(data.Regions); err != nil {
+			return err
+		}
+		workingHours, err := time_interval.ParseAll(data.WorkingHours)
+		if err != nil {
+			return cerrors.BadRequest.Wrap(err, "invalid working hours of hypothetical courier")
+		}
+		input.problem.Couriers = append(input.problem.Couriers, assignment.Courier{
+			CourierId:    -int64(i + 1),
+			CourierType:  data.CourierType,
+			Regions:      data.Regions,
+			WorkingHours: workingHours,
+		})
+	}
+
+	for i, data := range whatIf.Orders {
+		if err := s.regionService.ValidateRegions([]int64{data.Regions}); err != nil {
+			return err
+		}
+		windows, err := time_interval.ParseAll(data.DeliveryHours)
+		if err != nil {
+			return cerrors.BadRequest.Wrap(err, "invalid delivery hours of hypothetical order")
+		}
+		order := &model.Order{
+			OrderId:       -int64(i + 1),
+			Weight:        data.Weight,
+			Regions:       data.Regions,
+			DeliveryHours: data.DeliveryHours,
+			Cost:          *data.Cost,
+		}
+		input.orders[order.OrderId] = order
+		input.problem.Orders = append(input.problem.Orders, toAssignmentOrder(order, windows))
+	}
+	return nil
+}
+
+// pinnedGroup returns the group with only its pinned orders, sequenced again if the courier type is known.
+// If the pinned orders cannot be sequenced, e.g. after a forced assignment, their stored arrivals are kept.
+func (s *AssignmentService) pinnedGroup(group *model.GroupOrder, pinned []*model.Order, courierType string) (*assignment.Group, error) {
+	kept := *group
+	kept.Orders = pinned
+	result, err := toAssignmentGroup(&kept)
+	if err != nil || courierType == "" || len(pinned) == len(group.Orders) {
+		return result, err
+	}
+
+	if sequenced, ok := s.planner.Sequence(courierType, group.StartMinute, result.Orders); ok {
+		sequenced.GroupOrderId = group.GroupOrderId
+		return sequenced, nil
+	}
+	return result, nil
+}
+
+func (s *AssignmentService) checkedIn(couriers []*model.Courier) ([]*model.Courier, error) {
+	ids, err := s.shiftRepository.GetCheckedInCourierIds()
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		open[id] = true
+	}
+
+	result := make([]*model.Courier, 0, len(couriers))
+	for _, courier := range couriers {
+		if open[courier.CourierId] {
+			result = append(result, courier)
+		}
+	}
+	return result, nil
+}
+
+func averageDaily(total int64, days int) int64 {
+	if days <= 0 {
+		return 0
+	}
+	return total / int64(days)
+}
+
+func splitPinned(orders []*model.Order) ([]*model.Order, []*model.Order) {
+	var pinned, unpinned []*model.Order
+	for _, order := range orders {
+		if order.Pinned {
+			pinned = append(pinned, order)
+		} else {
+			unpinned = append(unpinned, order)
+		}
+	}
+	return pinned, unpinned
+}
+
+func toAssignmentOrder(order *model.Order, windows []time_interval.Interval) assignment.Order {
+	return assignment.Order{
+		OrderId:       order.OrderId,
+		Weight:        order.Weight,
+		Region:        order.Regions,
+		DeliveryHours: windows,
+		Cost:          order.Cost,
+		Priority:      order.Priority,
+	}
+}
+
+func toAssignmentGroup(group *model.GroupOrder) (*assignment.Group, error) {
+	result := &assignment.Group{
+		GroupOrderId: group.GroupOrderId,
+		Orders:       make([]assignment.Order, len(group.Orders)),
+		Arrivals:     make([]int, len(group.Orders)),
+		Start:        group.StartMinute,
+		Finish:       group.FinishMinute,
+		Fixed:        true,
+	}
+	for i, order := range group.Orders {
+		// delivery hours of a started group are not checked again
+		windows, _ := time_interval.ParseAll(order.DeliveryHours)
+		result.Orders[i] = toAssignmentOrder(order, windows)
+		result.Arrivals[i] = group.StartMinute
+		if order.PlannedArrival != nil {
+			arrival, err := parseClock(*order.PlannedArrival)
+			if err != nil {
+				return nil, cerrors.Wrapf(err, "invalid planned arrival of order '%v'", order.OrderId)
+			}
+			result.Arrivals[i] = arrival
+		}
+	}
+	return result, nil
+}
+
+func toGroupOrdersData(group *model.GroupOrder) service_data.NewGroupOrdersData {
+	data := service_data.NewGroupOrdersData{
+		GroupOrderId: group.GroupOrderId,
+		Orders:       make([]service_data.NewOrderDtoData, len(group.Orders)),
+	}
+	for i, order := range group.Orders {
+		data.Orders[i] = toOrderDtoData(order)
+	}
+	return data
+}
+
+func toOrderDtoData(order *model.Order) service_data.NewOrderDtoData {
+	return service_data.NewOrderDtoData{
+		OrderId:        order.OrderId,
+		Weight:         order.Weight,
+		Regions:        order.Regions,
+		DeliveryHours:  order.DeliveryHours,
+		Cost:           order.Cost,
+		CompletedTime:  order.CompletedTime,
+		PlannedArrival: order.PlannedArrival,
+	}
+}
+
+func toMetricsData(score assignment.Score, skipped int) *service_data.NewAssignmentMetricsData {
+	data := &service_data.NewAssignmentMetricsData{
+		DeliveredCost: score.DeliveredCost,
+		Assigned:      score.Assigned,
+		Unassigned:    score.Unassigned + skipped,
+		Utilization:   score.Utilization,
+		Fairness:      make([]service_data.NewTypeFairnessData, len(score.Fairness)),
+	}
+	for i, fairness := range score.Fairness {
+		data.Fairness[i] = service_data.NewTypeFairnessData(fairness)
+	}
+	return data
+}
+
+func parseClock(str string) (int, error) {
+	t, err := time.Parse(clockLayout, str)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatClock(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// today returns the current UTC date.
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// AuditScope collects the entity changes made while serving one request.
+type AuditScope struct {
+	Actor     string
+	RequestId string
+
+	mutex   sync.Mutex
+	changes []model.AuditChange
+}
+
+func NewAuditScope(actor, requestId string) *AuditScope {
+	return &AuditScope{
+		Actor:     actor,
+		RequestId: requestId,
+	}
+}
+
+// Changes returns the changes recorded so far in the order they were made.
+func (s *AuditScope) Changes() []model.AuditChange {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]model.AuditChange{}, s.changes...)
+}
+
+type auditScopeKey struct{}
+
+func WithAuditScope(ctx context.Context, scope *AuditScope) context.Context {
+	return context.WithValue(ctx, auditScopeKey{}, scope)
+}
+
+func auditScopeOf(ctx context.Context) *AuditScope {
+	scope, _ := ctx.Value(auditScopeKey{}).(*AuditScope)
+	return scope
+}
+
+// actorOf is the actor of the request the context belongs to, changes outside of a request are made by the system.
+func actorOf(ctx context.Context) string {
+	if scope := auditScopeOf(ctx); scope != nil && scope.Actor != "" {
+		return scope.Actor
+	}
+	return model.ActorSystem
+}
+
+// recordChange is the service hook of the audit log, it is a no-op outside of an audited request.
+// Before is nil for a created entity.
+func recordChange(ctx context.Context, entityType string, entityId int64, before, after interface{}) {
+	scope := auditScopeOf(ctx)
+	if scope == nil {
+		return
+	}
+	change := model.AuditChange{
+		EntityType: entityType,
+		EntityId:   entityId,
+		Before:     snapshot(before),
+		After:      snapshot(after),
+	}
+	scope.mutex.Lock()
+	defer scope.mutex.Unlock()
+	scope.changes = append(scope.changes, change)
+}
+
+// snapshot is the JSON of the entity, nil for a nil entity.
+func snapshot(entity interface{}) []byte {
+	if entity == nil {
+		return nil
+	}
+	value, err := json.Marshal(entity)
+	if err != nil || string(value) == "null" {
+		return nil
+	}
+	return value
+}
+
+type fieldDiff struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// auditDiff maps every top level field that differs between the snapshots to its before and after values,
+// a missing field is null.
+func auditDiff(before, after []byte) ([]byte, error) {
+	beforeFields, err := snapshotFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]fieldDiff)
+	for name, from := range beforeFields {
+		if to := afterFields[name]; !bytes.Equal(from, to) {
+			diff[name] = fieldDiff{Before: from, After: orNull(to)}
+		}
+	}
+	for name, to := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			diff[name] = fieldDiff{Before: orNull(nil), After: to}
+		}
+	}
+	return json.Marshal(diff)
+}
+
+func snapshotFields(value []byte) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(value) == 0 {
+		return fields, nil
+	}
+	return fields, json.Unmarshal(value, &fields)
+}
+
+func orNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
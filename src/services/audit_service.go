@@ -0,0 +1,171 @@
+package services
+
+import (
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuditRetentionConfig configures the export of old audit entries.
+type AuditRetentionConfig struct {
+	// Days audit entries are kept in the table, older ones are exported to JSONL files and deleted.
+	// Entries are kept forever if it is 0.
+	Days      int
+	ExportDir string
+	// Interval is how often old entries are looked for.
+	Interval time.Duration
+}
+
+// auditExportBatch is the number of entries read and deleted at once by the export.
+const auditExportBatch = 1000
+
+type AuditService struct {
+	auditRepository *repositories.AuditRepository
+	retention       AuditRetentionConfig
+}
+
+func NewAuditService(r *repositories.AuditRepository, retention AuditRetentionConfig) *AuditService {
+	return &AuditService{
+		auditRepository: r,
+		retention:       retention,
+	}
+}
+
+// RecordRequest writes an entry for every change recorded in the scope of the request,
+// or a single entry without an entity if the request changed nothing the services track.
+func (s *AuditService) RecordRequest(scope *AuditScope, method, route string, entityIds map[string]string, status int) error {
+	request := model.AuditEntry{
+		Actor:     scope.Actor,
+		Method:    method,
+		Route:     route,
+		EntityIds: entityIds,
+		RequestId: scope.RequestId,
+		Status:    status,
+	}
+
+	changes := scope.Changes()
+	if len(changes) == 0 {
+		return s.auditRepository.CreateEntries([]*model.AuditEntry{&request})
+	}
+	entries := make([]*model.AuditEntry, len(changes))
+	for i := range changes {
+		diff, err := auditDiff(changes[i].Before, changes[i].After)
+		if err != nil {
+			return err
+		}
+		entry := request
+		entry.EntityType = &changes[i].EntityType
+		entry.EntityId = &changes[i].EntityId
+		entry.Before = changes[i].Before
+		entry.After = changes[i].After
+		entry.Diff = diff
+		entries[i] = &entry
+	}
+	return s.auditRepository.CreateEntries(entries)
+}
+
+func (s *AuditService) GetEntries(filter service_data.AuditFilterData) ([]*model.AuditEntry, error) {
+	return s.auditRepository.GetEntries(filter)
+}
+
+// RunRetention exports the entries older than the retention every interval, it does nothing if the retention is off.
+func (s *AuditService) RunRetention() {
+	if s.retention.Days <= 0 {
+		return
+	}
+	for {
+		before := time.Now().AddDate(0, 0, -s.retention.Days)
+		if count, err := s.Export(before); err != nil {
+			log.Printf("audit export failed: %s", err.Error())
+		} else if count > 0 {
+			log.Printf("exported %d audit entries created before %s", count, before.Format(time.RFC3339))
+		}
+		time.Sleep(s.retention.Interval)
+	}
+}
+
+// Export appends the entries created before the time to a JSONL file in the export directory and deletes them.
+// Entries are deleted only after they are written, an entry may be exported twice if the deletion fails.
+func (s *AuditService) Export(before time.Time) (int, error) {
+	if err := os.MkdirAll(s.retention.ExportDir, 0o755); err != nil {
+		return 0, err
+	}
+	path := filepath.Join(s.retention.ExportDir, fmt.Sprintf("audit-%s.jsonl", before.UTC().Format("20060102T150405")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	for {
+		entries, err := s.auditRepository.GetEntriesBefore(before, auditExportBatch)
+		if err != nil || len(entries) == 0 {
+			return count, err
+		}
+		if err = writeAuditEntries(file, entries); err != nil {
+			return count, err
+		}
+		ids := make([]int64, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.Id
+		}
+		if err = s.auditRepository.DeleteEntries(ids); err != nil {
+			return count, err
+		}
+		count += len(entries)
+	}
+}
+
+// exportedAuditEntry is a line of an audit export file.
+type exportedAuditEntry struct {
+	Id         int64             `json:"id"`
+	Actor      string            `json:"actor"`
+	Method     string            `json:"method"`
+	Route      string            `json:"route"`
+	EntityIds  map[string]string `json:"entity_ids"`
+	EntityType *string           `json:"entity_type"`
+	EntityId   *int64            `json:"entity_id"`
+	Before     json.RawMessage   `json:"before"`
+	After      json.RawMessage   `json:"after"`
+	Diff       json.RawMessage   `json:"diff"`
+	RequestId  string            `json:"request_id"`
+	Status     int               `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+func writeAuditEntries(file *os.File, entries []*model.AuditEntry) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		err := encoder.Encode(exportedAuditEntry{
+			Id:         entry.Id,
+			Actor:      entry.Actor,
+			Method:     entry.Method,
+			Route:      entry.Route,
+			EntityIds:  entry.EntityIds,
+			EntityType: entry.EntityType,
+			EntityId:   entry.EntityId,
+			Before:     orNull(entry.Before),
+			After:      orNull(entry.After),
+			Diff:       orNull(entry.Diff),
+			RequestId:  entry.RequestId,
+			Status:     entry.Status,
+			CreatedAt:  entry.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
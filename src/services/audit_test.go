@@ -0,0 +1,41 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAuditDiff(t *testing.T) {
+	diff, err := auditDiff([]byte(`{"Active":true,"Regions":[1,2],"Archived":false}`), []byte(`{"Active":false,"Regions":[1,2],"Archived":false}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Active":{"before":true,"after":false}}`, string(diff))
+
+	diff, err = auditDiff(nil, []byte(`{"OrderId":5,"Cost":100}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"OrderId":{"before":null,"after":5},"Cost":{"before":null,"after":100}}`, string(diff))
+
+	diff, err = auditDiff([]byte(`{"Cost":100}`), []byte(`{"Cost":100}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(diff))
+}
+
+func TestRecordChange(t *testing.T) {
+	// outside of an audited request changes are not recorded and made by the system
+	recordChange(context.Background(), model.AuditEntityOrder, 1, nil, &model.Order{OrderId: 1})
+	require.Equal(t, model.ActorSystem, actorOf(context.Background()))
+
+	scope := NewAuditScope("dispatcher", "request")
+	ctx := WithAuditScope(context.Background(), scope)
+	var missing *model.Courier
+	recordChange(ctx, model.AuditEntityCourier, 7, missing, &model.Courier{CourierId: 7, Active: true})
+
+	require.Equal(t, "dispatcher", actorOf(ctx))
+	changes := scope.Changes()
+	require.Len(t, changes, 1)
+	require.Equal(t, model.AuditEntityCourier, changes[0].EntityType)
+	require.Equal(t, int64(7), changes[0].EntityId)
+	require.Nil(t, changes[0].Before)
+	require.Contains(t, string(changes[0].After), `"Active":true`)
+}
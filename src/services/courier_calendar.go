@@ -0,0 +1,92 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/time_interval"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// courierCalendar resolves the effective working hours of couriers for a date
+// from their daily template, weekday schedules and dated overrides.
+type courierCalendar struct {
+	weekly    map[int64]map[int][]string
+	overrides map[int64]map[string][]*model.ScheduleOverride
+}
+
+func newCourierCalendar(schedules []*model.CourierSchedule, overrides []*model.ScheduleOverride) *courierCalendar {
+	c := &courierCalendar{
+		weekly:    make(map[int64]map[int][]string),
+		overrides: make(map[int64]map[string][]*model.ScheduleOverride),
+	}
+	for _, schedule := range schedules {
+		if c.weekly[schedule.CourierId] == nil {
+			c.weekly[schedule.CourierId] = make(map[int][]string)
+		}
+		c.weekly[schedule.CourierId][schedule.Weekday] = schedule.WorkingHours
+	}
+	for _, override := range overrides {
+		if c.overrides[override.CourierId] == nil {
+			c.overrides[override.CourierId] = make(map[string][]*model.ScheduleOverride)
+		}
+		key := override.Date.Format(dateLayout)
+		c.overrides[override.CourierId][key] = append(c.overrides[override.CourierId][key], override)
+	}
+	return c
+}
+
+// day returns the working hours of the courier for the date. A weekday schedule replaces
+// the daily template, a day off or a sick day clears the hours and extra shifts are added on top.
+func (c *courierCalendar) day(courier *model.Courier, date time.Time) (*model.CalendarDay, error) {
+	hours := courier.WorkingHours
+	if weekdayHours, ok := c.weekly[courier.CourierId][isoWeekday(date)]; ok {
+		hours = weekdayHours
+	}
+
+	overrides := c.overrides[courier.CourierId][date.Format(dateLayout)]
+	var extraHours []string
+	for _, override := range overrides {
+		switch override.Kind {
+		case model.OverrideDayOff, model.OverrideSickDay:
+			hours = nil
+		case model.OverrideExtraShift:
+			extraHours = append(extraHours, override.WorkingHours...)
+		}
+	}
+
+	intervals, err := time_interval.ParseAll(append(append([]string{}, hours...), extraHours...))
+	if err != nil {
+		return nil, err
+	}
+	return &model.CalendarDay{
+		Date:         date,
+		WorkingHours: time_interval.Strings(time_interval.Merge(intervals)),
+		Overrides:    overrides,
+	}, nil
+}
+
+// scheduledMinutes returns the total working minutes of the courier for the dates in [from, to).
+func (c *courierCalendar) scheduledMinutes(courier *model.Courier, from, to time.Time) (int, error) {
+	total := 0
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		day, err := c.day(courier, date)
+		if err != nil {
+			return 0, err
+		}
+		intervals, err := time_interval.ParseAll(day.WorkingHours)
+		if err != nil {
+			return 0, err
+		}
+		total += time_interval.TotalMinutes(intervals)
+	}
+	return total, nil
+}
+
+func isoWeekday(date time.Time) int {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		return 7
+	}
+	return weekday
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCourierCalendarDay(t *testing.T) {
+	courier := &model.Courier{CourierId: 1, WorkingHours: []string{"09:00-18:00"}}
+	schedules := []*model.CourierSchedule{
+		{CourierId: 1, Weekday: 6, WorkingHours: []string{"10:00-14:00"}},
+	}
+	overrides := []*model.ScheduleOverride{
+		{CourierId: 1, Date: date("2023-05-15"), Kind: model.OverrideSickDay},
+		{CourierId: 1, Date: date("2023-05-16"), Kind: model.OverrideExtraShift, WorkingHours: []string{"17:00-21:00"}},
+		{CourierId: 1, Date: date("2023-05-17"), Kind: model.OverrideDayOff},
+		{CourierId: 1, Date: date("2023-05-17"), Kind: model.OverrideExtraShift, WorkingHours: []string{"19:00-21:00"}},
+	}
+	calendar := newCourierCalendar(schedules, overrides)
+
+	cases := map[string][]string{
+		"2023-05-14": {"09:00-18:00"}, //Sunday uses the daily template
+		"2023-05-15": {},
+		"2023-05-16": {"09:00-21:00"},
+		"2023-05-17": {"19:00-21:00"},
+		"2023-05-20": {"10:00-14:00"}, //Saturday uses the weekday schedule
+	}
+	for day, expected := range cases {
+		result, err := calendar.day(courier, date(day))
+		require.NoError(t, err)
+		require.Equal(t, expected, result.WorkingHours, day)
+	}
+
+	minutes, err := calendar.scheduledMinutes(courier, date("2023-05-14"), date("2023-05-18"))
+	require.NoError(t, err)
+	require.Equal(t, (9+12+2)*60, minutes)
+}
+
+func date(str string) time.Time {
+	d, _ := time.Parse(dateLayout, str)
+	return d
+}
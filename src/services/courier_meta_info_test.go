@@ -0,0 +1,28 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBlendRating(t *testing.T) {
+	quality := &model.Quality{Score: 4.5, Feedbacks: 2}
+
+	require.Equal(t, int64(2), blendRating(2, nil, 0.5, 0.5, 6))
+	require.Equal(t, int64(2), blendRating(2, quality, 0, 0, 6))
+	require.Equal(t, int64(2), blendRating(2, quality, 1, 0, 6))
+	require.Equal(t, int64(2), blendRating(2, quality, 1, 1, 0))
+	require.Equal(t, int64(5), blendRating(2, quality, 0, 1, 6), "5.25")
+	require.Equal(t, int64(4), blendRating(2, quality, 1, 1, 6), "3.625")
+	require.Equal(t, int64(6), blendRating(10, quality, 1, 1, 6), "throughput is capped at the scale, 5.625")
+	require.Equal(t, int64(10), blendRating(10, quality, 1, 0, 6))
+}
+
+func TestBlendRatingScalesFeedbackScores(t *testing.T) {
+	require.Equal(t, int64(0), blendRating(0, &model.Quality{Score: 1, Feedbacks: 1}, 1, 1, 6), "the lowest score is 0")
+	require.Equal(t, int64(6), blendRating(6, &model.Quality{Score: 5, Feedbacks: 1}, 1, 1, 6), "the top score is the scale")
+	require.Equal(t, int64(3), blendRating(0, &model.Quality{Score: 5, Feedbacks: 1}, 1, 1, 6))
+	require.Equal(t, int64(3), blendRating(6, &model.Quality{Score: 1, Feedbacks: 1}, 1, 1, 6))
+	require.Equal(t, int64(4), blendRating(0, &model.Quality{Score: 3, Feedbacks: 1}, 1, 3, 10), "3.75")
+}
@@ -1,18 +1,59 @@
 package services
 
 import (
+	cerrors "Ya.SumSchool23/controllers/errors"
 	"Ya.SumSchool23/repositories"
 	"Ya.SumSchool23/services/model"
 	"Ya.SumSchool23/services/service_data"
+	"Ya.SumSchool23/time_interval"
+	"context"
+	"math"
+	"time"
 )
 
+// MetaInfoConfig configures courier meta-info calculation.
+type MetaInfoConfig struct {
+	// RatingFromShifts makes rating use the hours of real shifts instead of the scheduled hours.
+	RatingFromShifts bool
+	// ThroughputWeight and QualityWeight blend the throughput rating with the average feedback score,
+	// the rating is the throughput alone if both are 0.
+	ThroughputWeight float64
+	QualityWeight    float64
+	// RatingScale is the throughput rating regarded as top. Both parts are scaled to [0, RatingScale] before
+	// blending, so the weights are their shares in the rating.
+	RatingScale int64
+}
+
 type CourierService struct {
-	courierRepository *repositories.CourierRepository
+	courierRepository    *repositories.CourierRepository
+	scheduleRepository   *repositories.ScheduleRepository
+	shiftRepository      *repositories.ShiftRepository
+	orderRepository      *repositories.OrderRepository
+	assignmentRepository *repositories.AssignmentRepository
+	ledgerRepository     *repositories.LedgerRepository
+	regionService        *RegionService
+	metaInfoConfig       MetaInfoConfig
 }
 
-func NewCourierService(r *repositories.CourierRepository) *CourierService {
+func NewCourierService(
+	r *repositories.CourierRepository,
+	sr *repositories.ScheduleRepository,
+	shr *repositories.ShiftRepository,
+	or *repositories.OrderRepository,
+	ar *repositories.AssignmentRepository,
+	lr *repositories.LedgerRepository,
+	rs *RegionService,
+	metaInfoConfig MetaInfoConfig,
+) *CourierService {
 	return &CourierService{
-		courierRepository: r,
+		courierRepository:    r,
+		scheduleRepository:   sr,
+		shiftRepository:      shr,
+		orderRepository:      or,
+		assignmentRepository: ar,
+		ledgerRepository:     lr,
+		regionService:        rs,
+		metaInfoConfig:       metaInfoConfig,
 	}
 }
 
@@ -24,9 +65,15 @@ func (s *CourierService) GetCouriers(limit int64, offset int64) ([]*model.Courie
 	return s.courierRepository.GetCouriers(limit, offset)
 }
 
-func (s *CourierService) CreateCouriers(data []service_data.NewCourierData) ([]*model.Courier, error) {
+func (s *CourierService) CreateCouriers(ctx context.Context, data []service_data.NewCourierData) ([]*model.Courier, error) {
 	result := make([]*model.Courier, 0)
 
+	for i := 0; i < len(data); i++ {
+		if err := s.regionService.ValidateRegions(data[i].Regions); err != nil {
+			return nil, err
+		}
+	}
+
 	courierIds, err := s.courierRepository.CreateCouriers(data)
 	if err != nil {
 		return nil, err
@@ -36,7 +83,314 @@ func (s *CourierService) CreateCouriers(data []service_data.NewCourierData) ([]*
 		if err != nil {
 			return nil, err
 		}
+		recordChange(ctx, model.AuditEntityCourier, id, nil, courier)
 		result = append(result, courier)
 	}
 	return result, nil
 }
+
+// GetAvailableCouriers returns active couriers, optionally working in the region
+// and having working hours on the date.
+func (s *CourierService) GetAvailableCouriers(region *int64, date *time.Time, limit, offset int64) ([]*model.Courier, error) {
+	couriers, err := s.courierRepository.GetAvailableCouriers(region)
+	if err != nil {
+		return nil, err
+	}
+
+	if date != nil {
+		calendar, err := s.loadCalendar(couriers, *date, date.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+		working := make([]*model.Courier, 0, len(couriers))
+		for _, courier := range couriers {
+			day, err := calendar.day(courier, *date)
+			if err != nil {
+				return nil, err
+			}
+			if len(day.WorkingHours) > 0 {
+				working = append(working, courier)
+			}
+		}
+		couriers = working
+	}
+
+	if offset >= int64(len(couriers)) {
+		return []*model.Courier{}, nil
+	}
+	end := offset + limit
+	if end > int64(len(couriers)) {
+		end = int64(len(couriers))
+	}
+	return couriers[offset:end], nil
+}
+
+// DeactivateCourier takes the courier out of assignment and availability search.
+// Orders of the courier's group orders not completed yet are released back to the order pool.
+// An archived courier is also hidden from the availability search until restored.
+func (s *CourierService) DeactivateCourier(ctx context.Context, id int64, archive bool) (*model.Courier, error) {
+	return s.updateCourier(ctx, id, func() error {
+		return s.assignmentRepository.DeactivateCourier(id, archive, today(), actorOf(ctx))
+	})
+}
+
+// ActivateCourier restores a deactivated or archived courier.
+func (s *CourierService) ActivateCourier(ctx context.Context, id int64) (*model.Courier, error) {
+	return s.updateCourier(ctx, id, func() error {
+		return s.courierRepository.UpdateCourierStatus(id, true, false)
+	})
+}
+
+// updateCourier applies the update and records the courier before and after it in the audit log.
+func (s *CourierService) updateCourier(ctx context.Context, id int64, update func() error) (*model.Courier, error) {
+	before, err := s.courierRepository.GetCourierById(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = update(); err != nil {
+		return nil, err
+	}
+	after, err := s.courierRepository.GetCourierById(id)
+	if err != nil {
+		return nil, err
+	}
+	recordChange(ctx, model.AuditEntityCourier, id, before, after)
+	return after, nil
+}
+
+func (s *CourierService) GetWeeklySchedule(courierId int64) ([]*model.CourierSchedule, error) {
+	if _, err := s.courierRepository.GetCourierById(courierId); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepository.GetWeeklySchedules([]int64{courierId})
+}
+
+func (s *CourierService) SetWeeklySchedule(courierId int64, data []service_data.NewCourierScheduleData) ([]*model.CourierSchedule, error) {
+	if _, err := s.courierRepository.GetCourierById(courierId); err != nil {
+		return nil, err
+	}
+
+	weekdays := make(map[int]bool)
+	for i := 0; i < len(data); i++ {
+		if weekdays[data[i].Weekday] {
+			return nil, cerrors.BadRequest.Newf("weekday '%v' is specified more than once", data[i].Weekday)
+		}
+		weekdays[data[i].Weekday] = true
+		if _, err := time_interval.ParseAll(data[i].WorkingHours); err != nil {
+			return nil, cerrors.BadRequest.Wrap(err, "invalid weekday working hours")
+		}
+	}
+
+	if err := s.scheduleRepository.ReplaceWeeklySchedule(courierId, data); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepository.GetWeeklySchedules([]int64{courierId})
+}
+
+func (s *CourierService) CreateScheduleOverride(data service_data.NewScheduleOverrideData) (*model.ScheduleOverride, error) {
+	if _, err := s.courierRepository.GetCourierById(data.CourierId); err != nil {
+		return nil, err
+	}
+	if data.Kind == model.OverrideExtraShift && len(data.WorkingHours) == 0 {
+		return nil, cerrors.BadRequest.New("extra shift must have working hours")
+	}
+	if _, err := time_interval.ParseAll(data.WorkingHours); err != nil {
+		return nil, cerrors.BadRequest.Wrap(err, "invalid override working hours")
+	}
+
+	id, err := s.scheduleRepository.CreateOverride(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.scheduleRepository.GetOverrideById(id)
+}
+
+func (s *CourierService) DeleteScheduleOverride(courierId, overrideId int64) error {
+	return s.scheduleRepository.DeleteOverride(courierId, overrideId)
+}
+
+// GetCalendar returns the effective working hours of the courier for every date in [from, to).
+func (s *CourierService) GetCalendar(courierId int64, from, to time.Time) ([]*model.CalendarDay, error) {
+	courier, err := s.courierRepository.GetCourierById(courierId)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar, err := s.loadCalendar([]*model.Courier{courier}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]*model.CalendarDay, 0)
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		day, err := calendar.day(courier, date)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// GetWorkingHours returns the working hours of every courier for the date by courier id.
+func (s *CourierService) GetWorkingHours(couriers []*model.Courier, date time.Time) (map[int64][]time_interval.Interval, error) {
+	calendar, err := s.loadCalendar(couriers, date, date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64][]time_interval.Interval, len(couriers))
+	for _, courier := range couriers {
+		day, err := calendar.day(courier, date)
+		if err != nil {
+			return nil, err
+		}
+		if result[courier.CourierId], err = time_interval.ParseAll(day.WorkingHours); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// GetCourierMetaInfo calculates earnings and rating of the courier for orders completed in [from, to).
+// Earnings are the ledger earnings dated in that period, as they were written on completion.
+// Rating is based on the hours the courier was scheduled to work in that period,
+// or on the hours of real shifts if MetaInfoConfig.RatingFromShifts is set, and blended with the feedback quality.
+func (s *CourierService) GetCourierMetaInfo(courierId int64, from, to time.Time) (*model.CourierMetaInfo, error) {
+	courier, err := s.courierRepository.GetCourierById(courierId)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderRepository.GetCompletedOrders(courierId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	metaInfo := &model.CourierMetaInfo{Courier: courier}
+	if len(orders) == 0 {
+		return metaInfo, nil
+	}
+
+	earnings, err := s.ledgerRepository.SumEarnings([]int64{courierId}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	courierEarnings := earnings[courierId]
+	metaInfo.Earnings = &courierEarnings
+
+	if metaInfo.Quality, err = s.orderRepository.GetQuality(courierId, from, to); err != nil {
+		return nil, err
+	}
+
+	minutes, err := s.ratingMinutes(courier, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if minutes > 0 {
+		throughput := int64(len(orders)) * 60 * model.CourierTypeProfiles[courier.CourierType].RatingCoefficient / int64(minutes)
+		rating := blendRating(throughput, metaInfo.Quality,
+			s.metaInfoConfig.ThroughputWeight, s.metaInfoConfig.QualityWeight, s.metaInfoConfig.RatingScale)
+		metaInfo.Rating = &rating
+	}
+	return metaInfo, nil
+}
+
+// blendRating is the weighted mean of the throughput rating and the quality score, rounded. Both are scaled to
+// [0, scale] first: the throughput is capped at the scale and the score of 1 to 5 is mapped linearly onto it.
+// Without feedback, quality weight or scale the rating is the throughput.
+func blendRating(throughput int64, quality *model.Quality, throughputWeight, qualityWeight float64, scale int64) int64 {
+	if quality == nil || qualityWeight <= 0 || throughputWeight < 0 || scale <= 0 {
+		return throughput
+	}
+	throughputPart := math.Min(float64(throughput), float64(scale))
+	qualityPart := (quality.Score - 1) / 4 * float64(scale)
+	blended := (throughputWeight*throughputPart + qualityWeight*qualityPart) / (throughputWeight + qualityWeight)
+	return int64(math.Round(blended))
+}
+
+// GetEarnings returns the ledger earnings of every courier dated in [from, to) by courier id.
+func (s *CourierService) GetEarnings(couriers []*model.Courier, from, to time.Time) (map[int64]int64, error) {
+	ids := make([]int64, len(couriers))
+	for i, courier := range couriers {
+		ids[i] = courier.CourierId
+	}
+	earnings, err := s.ledgerRepository.SumEarnings(ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64]int64, len(couriers))
+	for _, courier := range couriers {
+		result[courier.CourierId] = earnings[courier.CourierId]
+	}
+	return result, nil
+}
+
+func (s *CourierService) ratingMinutes(courier *model.Courier, from, to time.Time) (int, error) {
+	if s.metaInfoConfig.RatingFromShifts {
+		shifts, err := s.shiftRepository.GetShifts(courier.CourierId, from, to)
+		if err != nil {
+			return 0, err
+		}
+		return shiftMinutes(shifts, from, to, time.Now()), nil
+	}
+
+	calendar, err := s.loadCalendar([]*model.Courier{courier}, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return calendar.scheduledMinutes(courier, from, to)
+}
+
+// StartShift checks the courier in, only active couriers can start a shift.
+func (s *CourierService) StartShift(courierId int64) (*model.Shift, error) {
+	courier, err := s.courierRepository.GetCourierById(courierId)
+	if err != nil {
+		return nil, err
+	}
+	if !courier.Active {
+		return nil, cerrors.BadRequest.Newf("courier with id = '%v' is not active", courierId)
+	}
+
+	id, err := s.shiftRepository.StartShift(courierId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.shiftRepository.GetShiftById(id)
+}
+
+func (s *CourierService) EndShift(courierId int64) (*model.Shift, error) {
+	if _, err := s.courierRepository.GetCourierById(courierId); err != nil {
+		return nil, err
+	}
+
+	id, err := s.shiftRepository.EndShift(courierId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.shiftRepository.GetShiftById(id)
+}
+
+// GetShifts returns shifts of the courier overlapping [from, to).
+func (s *CourierService) GetShifts(courierId int64, from, to time.Time) ([]*model.Shift, error) {
+	if _, err := s.courierRepository.GetCourierById(courierId); err != nil {
+		return nil, err
+	}
+	return s.shiftRepository.GetShifts(courierId, from, to)
+}
+
+func (s *CourierService) loadCalendar(couriers []*model.Courier, from, to time.Time) (*courierCalendar, error) {
+	ids := make([]int64, len(couriers))
+	for i, courier := range couriers {
+		ids[i] = courier.CourierId
+	}
+
+	schedules, err := s.scheduleRepository.GetWeeklySchedules(ids)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.scheduleRepository.GetOverrides(ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return newCourierCalendar(schedules, overrides), nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"time"
+)
+
+// shiftMinutes returns the total minutes of the shifts inside [from, to), open shifts are counted up to now.
+func shiftMinutes(shifts []*model.Shift, from, to, now time.Time) int {
+	var total time.Duration
+	for _, shift := range shifts {
+		start := shift.StartedAt
+		if start.Before(from) {
+			start = from
+		}
+		end := now
+		if shift.EndedAt != nil {
+			end = *shift.EndedAt
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return int(total.Minutes())
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestShiftMinutes(t *testing.T) {
+	at := func(str string) time.Time {
+		result, _ := time.Parse(time.RFC3339, str)
+		return result
+	}
+	endedAt := func(str string) *time.Time {
+		result := at(str)
+		return &result
+	}
+
+	shifts := []*model.Shift{
+		{StartedAt: at("2023-05-14T22:00:00Z"), EndedAt: endedAt("2023-05-15T02:00:00Z")},
+		{StartedAt: at("2023-05-15T09:00:00Z"), EndedAt: endedAt("2023-05-15T12:30:00Z")},
+		{StartedAt: at("2023-05-15T20:00:00Z")},
+	}
+
+	minutes := shiftMinutes(shifts, at("2023-05-15T00:00:00Z"), at("2023-05-16T00:00:00Z"), at("2023-05-15T21:00:00Z"))
+	require.Equal(t, 120+210+60, minutes)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"context"
+	"sync"
+	"time"
+)
+
+// EventStreamConfig configures live event streaming.
+type EventStreamConfig struct {
+	// PollInterval wakes subscriptions even without a notification, in case one was missed.
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// EventFilter selects streamed events, an unset field matches every event.
+type EventFilter struct {
+	Region    *int64
+	CourierId *int64
+}
+
+func (f EventFilter) Matches(event *model.StreamEvent) bool {
+	if f.CourierId != nil && (event.CourierId == nil || *event.CourierId != *f.CourierId) {
+		return false
+	}
+	if f.Region != nil {
+		for _, region := range event.Regions {
+			if region == *f.Region {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// EventStream streams outbox events to live clients. Every subscription reads the outbox from its own cursor
+// in outbox order, so a slow client misses nothing and a client can resume after the last event it got.
+// An event is streamed once every transaction older than its own has finished.
+// Subscriptions are woken by outbox notifications and every poll interval.
+type EventStream struct {
+	outboxRepository *repositories.OutboxRepository
+	config           EventStreamConfig
+
+	mutex         sync.Mutex
+	subscriptions map[*Subscription]bool
+}
+
+func NewEventStream(r *repositories.OutboxRepository, config EventStreamConfig) *EventStream {
+	return &EventStream{
+		outboxRepository: r,
+		config:           config,
+		subscriptions:    make(map[*Subscription]bool),
+	}
+}
+
+// Wake makes every subscription look for new events.
+func (s *EventStream) Wake() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for subscription := range s.subscriptions {
+		select {
+		case subscription.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RunPolling wakes subscriptions every poll interval forever.
+func (s *EventStream) RunPolling() {
+	for range time.Tick(s.config.PollInterval) {
+		s.Wake()
+	}
+}
+
+// Subscribe starts a subscription after the event id, at the start of the outbox if it is 0,
+// or after the newest event if it is nil.
+// The subscription must be closed.
+func (s *EventStream) Subscribe(filter EventFilter, afterId *int64) (*Subscription, error) {
+	subscription := &Subscription{
+		stream: s,
+		filter: filter,
+		wake:   make(chan struct{}, 1),
+	}
+	var err error
+	switch {
+	case afterId == nil:
+		subscription.cursor, err = s.outboxRepository.GetLastPosition()
+	case *afterId == 0:
+		subscription.cursor = model.OutboxPosition{TxId: "0"}
+	default:
+		subscription.cursor, err = s.outboxRepository.GetEventPosition(*afterId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subscriptions[subscription] = true
+	return subscription, nil
+}
+
+type Subscription struct {
+	stream *EventStream
+	filter EventFilter
+	cursor model.OutboxPosition
+	wake   chan struct{}
+}
+
+// Next returns the next events matching the filter, oldest first. It waits for them until the context is done
+// and returns the error of the context then.
+func (s *Subscription) Next(ctx context.Context) ([]*model.StreamEvent, error) {
+	for {
+		events, err := s.stream.outboxRepository.GetStreamEvents(s.cursor, s.stream.config.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		matched := make([]*model.StreamEvent, 0, len(events))
+		for _, event := range events {
+			s.cursor = model.OutboxPosition{TxId: event.TxId, EventId: event.Id}
+			if s.filter.Matches(event) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) > 0 {
+			return matched, nil
+		}
+		if len(events) == s.stream.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-s.wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *Subscription) Close() {
+	s.stream.mutex.Lock()
+	defer s.stream.mutex.Unlock()
+	delete(s.stream.subscriptions, s)
+}
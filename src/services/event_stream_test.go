@@ -0,0 +1,34 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEventFilter(t *testing.T) {
+	courierId, otherCourierId := int64(3), int64(4)
+	region, otherRegion := int64(7), int64(8)
+	orderCreated := &model.StreamEvent{Regions: []int64{region}}
+	orderAssigned := &model.StreamEvent{Regions: []int64{region}, CourierId: &courierId}
+	courierCreated := &model.StreamEvent{Regions: []int64{otherRegion, region}, CourierId: &otherCourierId}
+
+	all := EventFilter{}
+	require.True(t, all.Matches(orderCreated))
+	require.True(t, all.Matches(courierCreated))
+
+	byRegion := EventFilter{Region: &region}
+	require.True(t, byRegion.Matches(orderCreated))
+	require.True(t, byRegion.Matches(courierCreated))
+	byOtherRegion := EventFilter{Region: &otherRegion}
+	require.False(t, byOtherRegion.Matches(orderAssigned))
+	require.True(t, byOtherRegion.Matches(courierCreated))
+
+	byCourier := EventFilter{CourierId: &courierId}
+	require.False(t, byCourier.Matches(orderCreated))
+	require.True(t, byCourier.Matches(orderAssigned))
+	require.False(t, byCourier.Matches(courierCreated))
+
+	both := EventFilter{Region: &otherRegion, CourierId: &courierId}
+	require.False(t, both.Matches(orderAssigned))
+}
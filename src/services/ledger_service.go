@@ -0,0 +1,219 @@
+package services
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"time"
+)
+
+// LedgerConfig configures entries written automatically.
+type LedgerConfig struct {
+	// Pricing gives the effective cost of orders delivered in group orders.
+	Pricing pricing.Rules
+	// LateDeliveryPenalty is written for every order completed late, no penalty if 0.
+	LateDeliveryPenalty int64
+}
+
+type LedgerService struct {
+	ledgerRepository     *repositories.LedgerRepository
+	courierRepository    *repositories.CourierRepository
+	assignmentRepository *repositories.AssignmentRepository
+	config               LedgerConfig
+}
+
+func NewLedgerService(
+	r *repositories.LedgerRepository,
+	cr *repositories.CourierRepository,
+	ar *repositories.AssignmentRepository,
+	config LedgerConfig,
+) *LedgerService {
+	return &LedgerService{
+		ledgerRepository:     r,
+		courierRepository:    cr,
+		assignmentRepository: ar,
+		config:               config,
+	}
+}
+
+// GetLedger returns a page of the courier entries, the latest first, and the courier balance.
+func (s *LedgerService) GetLedger(courierId, limit, offset int64) ([]*model.LedgerEntry, int64, error) {
+	if _, err := s.courierRepository.GetCourierById(courierId); err != nil {
+		return nil, 0, err
+	}
+	entries, err := s.ledgerRepository.GetEntries(courierId, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	balance, err := s.ledgerRepository.GetBalance(courierId)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, balance, nil
+}
+
+// CreateEntry adds a bonus, penalty or payout. The amount is given positive,
+// penalties and payouts are stored negative. Earnings are only written on order completion.
+func (s *LedgerService) CreateEntry(data service_data.NewLedgerEntryData) (*model.LedgerEntry, error) {
+	if data.Amount <= 0 {
+		return nil, cerrors.BadRequest.Newf("amount must be positive, got %d", data.Amount)
+	}
+	switch data.Kind {
+	case model.LedgerKindBonus:
+	case model.LedgerKindPenalty:
+		if !validPenaltyReason(data.Reason) {
+			return nil, cerrors.BadRequest.Newf("unknown penalty reason '%s', expected %s, %s or %s",
+				data.Reason, model.PenaltyLateDelivery, model.PenaltyFailedDelivery, model.PenaltyOther)
+		}
+		data.Amount = -data.Amount
+	case model.LedgerKindPayout:
+		data.Amount = -data.Amount
+	default:
+		return nil, cerrors.BadRequest.Newf("unknown ledger entry kind '%s', expected %s, %s or %s",
+			data.Kind, model.LedgerKindBonus, model.LedgerKindPenalty, model.LedgerKindPayout)
+	}
+	if data.Kind != model.LedgerKindPenalty && data.Reason != "" {
+		return nil, cerrors.BadRequest.Newf("only penalties have a reason")
+	}
+	if _, err := s.courierRepository.GetCourierById(data.CourierId); err != nil {
+		return nil, err
+	}
+
+	id, err := s.ledgerRepository.CreateEntry(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.ledgerRepository.GetEntryById(id)
+}
+
+// CompletionEntries returns the earning of every order being completed and its late delivery penalty,
+// orders are given as they were before the completion. Orders completed already and completions by unknown couriers
+// get no entries. Entries are dated by the completion, the repository dates them today if its period is closed.
+func (s *LedgerService) CompletionEntries(
+	data []service_data.NewCompleteOrderData,
+	orders []*model.Order,
+) ([]service_data.NewLedgerEntryData, error) {
+	byId := make(map[int64]*model.Order, len(orders))
+	for _, order := range orders {
+		byId[order.OrderId] = order
+	}
+
+	var entries []service_data.NewLedgerEntryData
+	couriers := make(map[int64]*model.Courier)
+	for i := 0; i < len(data); i++ {
+		order, ok := byId[data[i].OrderId]
+		if !ok || order.CompletedTime != nil {
+			continue
+		}
+		courier, ok := couriers[data[i].CourierId]
+		if !ok {
+			var err error
+			courier, err = s.courierRepository.GetCourierById(data[i].CourierId)
+			if cerrors.GetType(err) == cerrors.NotFound {
+				// completions are not checked against couriers, an unknown courier has no ledger
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			couriers[courier.CourierId] = courier
+		}
+
+		date, err := completionDate(data[i].CompleteTime)
+		if err != nil {
+			return nil, err
+		}
+		earning, err := s.orderEarning(courier, order)
+		if err != nil {
+			return nil, err
+		}
+		orderId := order.OrderId
+		entries = append(entries, service_data.NewLedgerEntryData{
+			CourierId: courier.CourierId,
+			Kind:      model.LedgerKindEarning,
+			Amount:    earning,
+			OrderId:   &orderId,
+			Date:      date,
+		})
+		if s.config.LateDeliveryPenalty > 0 && data[i].LatenessMinutes != nil && *data[i].LatenessMinutes > 0 {
+			entries = append(entries, service_data.NewLedgerEntryData{
+				CourierId: courier.CourierId,
+				Kind:      model.LedgerKindPenalty,
+				Reason:    model.PenaltyLateDelivery,
+				Amount:    -s.config.LateDeliveryPenalty,
+				OrderId:   &orderId,
+				Date:      date,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// ClosePeriod pays out the balance of [from, to) and freezes its entries, the period must be over.
+func (s *LedgerService) ClosePeriod(from, to time.Time) (*model.PayoutPeriod, error) {
+	if !to.After(from) {
+		return nil, cerrors.BadRequest.Newf("'to' must be after 'from', got '%s' and '%s'",
+			to.Format(dateLayout), from.Format(dateLayout))
+	}
+	if to.After(today()) {
+		return nil, cerrors.BadRequest.Newf("period to '%s' is not over yet", to.Format(dateLayout))
+	}
+	id, err := s.ledgerRepository.ClosePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.ledgerRepository.GetPeriodById(id)
+}
+
+func (s *LedgerService) GetPeriodById(id int64) (*model.PayoutPeriod, error) {
+	return s.ledgerRepository.GetPeriodById(id)
+}
+
+// orderEarning prices the order at its planned place in its group order.
+func (s *LedgerService) orderEarning(courier *model.Courier, order *model.Order) (int64, error) {
+	var group []*model.Order
+	if order.GroupOrderId != nil {
+		groupOrder, err := s.assignmentRepository.GetGroupOrderById(*order.GroupOrderId)
+		if err != nil {
+			return 0, err
+		}
+		group = groupOrder.Orders
+	}
+	return earningOf(courier, order, group, s.config.Pricing), nil
+}
+
+// earningOf the order delivered in a group order whose orders are given in planned delivery order,
+// an order delivered without a group order is paid in full.
+func earningOf(courier *model.Courier, order *model.Order, group []*model.Order, rules pricing.Rules) int64 {
+	cost := order.Cost
+	costs := make([]int64, len(group))
+	for i, o := range group {
+		costs[i] = o.Cost
+	}
+	effective := rules.GroupCosts(courier.CourierType, costs)
+	for i, o := range group {
+		if o.OrderId == order.OrderId {
+			cost = effective[i]
+		}
+	}
+	return cost * model.CourierTypeProfiles[courier.CourierType].EarningsCoefficient
+}
+
+// completionDate is the UTC day of the completion time.
+func completionDate(completedTime string) (time.Time, error) {
+	completed, err := time.Parse(time.RFC3339, completedTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	completed = completed.UTC()
+	return time.Date(completed.Year(), completed.Month(), completed.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func validPenaltyReason(reason string) bool {
+	switch reason {
+	case model.PenaltyLateDelivery, model.PenaltyFailedDelivery, model.PenaltyOther:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEarningOfGroupOrders(t *testing.T) {
+	group := []*model.Order{
+		{OrderId: 2, Cost: 100},
+		{OrderId: 1, Cost: 200},
+	}
+	alone := &model.Order{OrderId: 3, Cost: 50}
+	courier := &model.Courier{CourierType: model.CourierTypeFoot}
+	rules := pricing.Rules{model.CourierTypeFoot: {NextOrderDiscount: 20}}
+
+	// the first order of the group is paid in full, the next ones at a discount: 200 * 0.8 * 2
+	require.Equal(t, int64(200), earningOf(courier, group[0], group, rules))
+	require.Equal(t, int64(320), earningOf(courier, group[1], group, rules))
+	require.Equal(t, int64(400), earningOf(courier, group[1], group, nil))
+	require.Equal(t, int64(100), earningOf(courier, alone, nil, rules))
+}
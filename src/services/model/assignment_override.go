@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// AssignmentOverride records a manual assignment of an order to a courier.
+type AssignmentOverride struct {
+	OverrideId        int64
+	OrderId           int64
+	CourierId         int64
+	PreviousCourierId *int64
+	GroupOrderId      int64
+	Date              time.Time
+	Forced            bool
+	CreatedAt         time.Time
+}
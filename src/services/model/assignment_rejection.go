@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// AssignmentRejection explains why an order was left unassigned by the last assignment run it took part in.
+type AssignmentRejection struct {
+	OrderId       int64
+	Date          time.Time
+	Strategy      string
+	Reason        string
+	NearestMisses []NearestMiss
+	CreatedAt     time.Time
+}
+
+// NearestMiss is a courier that came close to taking the order and the reason it could not.
+type NearestMiss struct {
+	CourierId int64
+	Reason    string
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Audited entity types.
+const (
+	AuditEntityCourier = "courier"
+	AuditEntityOrder   = "order"
+)
+
+// AuditChange is a change of an entity made while serving a request, Before is nil for a created entity.
+// Snapshots are JSON objects.
+type AuditChange struct {
+	EntityType string
+	EntityId   int64
+	Before     []byte
+	After      []byte
+}
+
+// AuditEntry is a mutating API call, one entry is written for every entity the call changed
+// or a single one without an entity if it changed none the services track.
+type AuditEntry struct {
+	Id     int64
+	Actor  string
+	Method string
+	Route  string
+	// EntityIds are the path params of the call
+	EntityIds  map[string]string
+	EntityType *string
+	EntityId   *int64
+	Before     []byte
+	After      []byte
+	// Diff maps every changed field to its before and after values
+	Diff      []byte
+	RequestId string
+	Status    int
+	CreatedAt time.Time
+}
@@ -1,8 +1,24 @@
 package model
 
+const (
+	CourierTypeFoot = "FOOT"
+	CourierTypeBike = "BIKE"
+	CourierTypeAuto = "AUTO"
+)
+
 type Courier struct {
 	CourierId    int64
 	CourierType  string
 	Regions      []int64
 	WorkingHours []string
+	Active       bool
+	Archived     bool
+}
+
+type CourierMetaInfo struct {
+	Courier  *Courier
+	Rating   *int64
+	Earnings *int64
+	// Quality is nil if the completed orders got no feedback
+	Quality *Quality
 }
@@ -0,0 +1,23 @@
+package model
+
+// CourierTypeProfile describes what a courier of some type can carry, how fast it delivers
+// and how its earnings and rating are calculated.
+type CourierTypeProfile struct {
+	MaxWeight  float64
+	MaxOrders  int
+	MaxRegions int
+	// FirstOrderMinutes is spent on the first order of a group, NextOrderMinutes on every next one.
+	FirstOrderMinutes   int
+	NextOrderMinutes    int
+	EarningsCoefficient int64
+	RatingCoefficient   int64
+}
+
+var CourierTypeProfiles = map[string]CourierTypeProfile{
+	CourierTypeFoot: {MaxWeight: 10, MaxOrders: 2, MaxRegions: 1, FirstOrderMinutes: 25, NextOrderMinutes: 10,
+		EarningsCoefficient: 2, RatingCoefficient: 3},
+	CourierTypeBike: {MaxWeight: 20, MaxOrders: 4, MaxRegions: 2, FirstOrderMinutes: 12, NextOrderMinutes: 8,
+		EarningsCoefficient: 3, RatingCoefficient: 2},
+	CourierTypeAuto: {MaxWeight: 40, MaxOrders: 7, MaxRegions: 3, FirstOrderMinutes: 8, NextOrderMinutes: 4,
+		EarningsCoefficient: 4, RatingCoefficient: 1},
+}
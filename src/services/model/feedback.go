@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// OrderFeedback is the customer score (1-5) of a completed order, given once per order.
+type OrderFeedback struct {
+	OrderId   int64
+	CourierId int64
+	Score     int
+	Comment   *string
+	CreatedAt time.Time
+}
+
+// Quality is the average feedback score of a courier.
+type Quality struct {
+	Score     float64
+	Feedbacks int64
+}
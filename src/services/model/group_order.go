@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// GroupOrder is a set of orders delivered by a courier in one trip, orders are in delivery order.
+// StartMinute and FinishMinute are minutes since midnight of the assignment date.
+type GroupOrder struct {
+	GroupOrderId int64
+	CourierId    int64
+	Date         time.Time
+	StartMinute  int
+	FinishMinute int
+	Strategy     string
+	Orders       []*Order
+}
+
+// Started reports whether the courier has already completed some order of the group.
+func (g *GroupOrder) Started() bool {
+	for _, order := range g.Orders {
+		if order.CompletedTime != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Ledger entry kinds. Earnings, bonuses and penalties change the courier balance, payouts settle it.
+const (
+	LedgerKindEarning = "earning"
+	LedgerKindBonus   = "bonus"
+	LedgerKindPenalty = "penalty"
+	LedgerKindPayout  = "payout"
+)
+
+// Penalty reasons.
+const (
+	PenaltyLateDelivery   = "late_delivery"
+	PenaltyFailedDelivery = "failed_delivery"
+	PenaltyOther          = "other"
+)
+
+// LedgerEntry is a change of the courier balance. Amount is negative for penalties and payouts.
+// Entries of a closed payout period (PeriodId is set) are frozen.
+type LedgerEntry struct {
+	EntryId   int64
+	CourierId int64
+	Kind      string
+	Reason    string
+	Amount    int64
+	OrderId   *int64
+	Comment   *string
+	Date      time.Time
+	PeriodId  *int64
+	CreatedAt time.Time
+}
+
+// PayoutPeriod is a closed period [From, To) with the payout of every courier that had a balance.
+type PayoutPeriod struct {
+	PeriodId int64
+	From     time.Time
+	To       time.Time
+	ClosedAt time.Time
+	Payouts  []*LedgerEntry
+}
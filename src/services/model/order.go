@@ -1,10 +1,37 @@
 package model
 
+import "time"
+
 type Order struct {
 	OrderId       int64
 	Weight        float64
 	Regions       int64
+	Lat           *float64
+	Lon           *float64
 	DeliveryHours []string
 	Cost          int64
 	CompletedTime *string
+	GroupOrderId  *int64
+	// PlannedArrival is the planned delivery time (HH:MM) within the group order
+	PlannedArrival *string
+	// Pinned orders stay with the courier of their group order when the assignment is re-run
+	Pinned bool
+	// Priority is preferred by the assignment, higher first
+	Priority   int
+	PromisedBy *time.Time
+	// LatenessMinutes is set on completion: minutes after promised_by or the end of the delivery hours
+	LatenessMinutes *int
+	// DeliveryDate is the date the delivery hours are on
+	DeliveryDate time.Time
+	// TariffVersion is the tariff the cost was calculated by, nil if the cost was given
+	TariffVersion *int64
+	// CompletedCourierId is the courier that completed the order
+	CompletedCourierId *int64
+}
+
+// LateRate counts late completed orders of a courier or a region.
+type LateRate struct {
+	Id        int64
+	Completed int64
+	Late      int64
 }
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Order event types, one for every transition of an order.
+const (
+	OrderEventCreated    = "created"
+	OrderEventAssigned   = "assigned"
+	OrderEventReassigned = "reassigned"
+	OrderEventUnassigned = "unassigned"
+	OrderEventCompleted  = "completed"
+)
+
+// Actors of transitions that are not requested through the API.
+const (
+	ActorSystem    = "system"
+	ActorReplanner = "replanner"
+)
+
+// OrderEvent is an entry of the append-only order history, Payload is a JSON object with the event details.
+type OrderEvent struct {
+	EventId   int64
+	OrderId   int64
+	Type      string
+	Actor     string
+	Payload   []byte
+	CreatedAt time.Time
+}
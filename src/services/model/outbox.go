@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// Domain event types published through the outbox. CourierUpdated is a change of the courier status:
+// activation, deactivation or archiving. Events of orders unassigned from a courier carry the courier id.
+const (
+	EventOrderCreated           = "OrderCreated"
+	EventOrderAssigned          = "OrderAssigned"
+	EventOrderUnassigned        = "OrderUnassigned"
+	EventOrderCompleted         = "OrderCompleted"
+	EventCourierCreated         = "CourierCreated"
+	EventCourierUpdated         = "CourierUpdated"
+	EventCourierScheduleUpdated = "CourierScheduleUpdated"
+	EventCourierShiftStarted    = "CourierShiftStarted"
+	EventCourierShiftEnded      = "CourierShiftEnded"
+)
+
+// Aggregate types of domain events.
+const (
+	AggregateOrder   = "order"
+	AggregateCourier = "courier"
+)
+
+// OutboxEvent is a domain event stored in the transaction of the change it describes, it is published
+// at least once by the outbox relays. Payload is a JSON object with the event details.
+// Events are ordered by TxId, the transaction that stored them, and then by Id.
+type OutboxEvent struct {
+	Id            int64
+	TxId          string
+	Type          string
+	AggregateType string
+	AggregateId   int64
+	Payload       []byte
+	CreatedAt     time.Time
+}
+
+// OutboxPosition is the place of an event in the outbox order.
+type OutboxPosition struct {
+	TxId    string
+	EventId int64
+}
+
+// OutboxRelayState is how far a relay got through the outbox: the last event it published
+// and the failed attempts to publish the next one.
+type OutboxRelayState struct {
+	Name string
+	OutboxPosition
+	Attempts  int
+	LastError *string
+}
+
+// StreamEvent is an outbox event with the regions and courier it concerns, streamed to live clients.
+// Regions are the region of an order or the regions of a courier, CourierId is set for events of a courier.
+type StreamEvent struct {
+	OutboxEvent
+	Regions   []int64
+	CourierId *int64
+}
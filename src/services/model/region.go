@@ -0,0 +1,17 @@
+package model
+
+type Region struct {
+	RegionId int64
+	Name     string
+	TimeZone string
+	Active   bool
+	Boundary []byte //GeoJSON Polygon or MultiPolygon, nil if not set
+}
+
+// RegionEdge is the travel time between neighbouring regions for a courier type.
+type RegionEdge struct {
+	FromRegion  int64
+	ToRegion    int64
+	CourierType string
+	Minutes     int64
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	OverrideDayOff     = "DAY_OFF"
+	OverrideSickDay    = "SICK_DAY"
+	OverrideExtraShift = "EXTRA_SHIFT"
+)
+
+// CourierSchedule is the working hours template of a courier for one ISO weekday (1 is Monday).
+type CourierSchedule struct {
+	CourierId    int64
+	Weekday      int
+	WorkingHours []string
+}
+
+// ScheduleOverride changes the working hours of a courier for a single date.
+type ScheduleOverride struct {
+	OverrideId   int64
+	CourierId    int64
+	Date         time.Time
+	Kind         string
+	WorkingHours []string
+	Comment      *string
+}
+
+// CalendarDay is the effective working hours of a courier for a date.
+type CalendarDay struct {
+	Date         time.Time
+	WorkingHours []string
+	Overrides    []*ScheduleOverride
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Shift is a period the courier was checked in, EndedAt is nil while the shift is open.
+type Shift struct {
+	ShiftId   int64
+	CourierId int64
+	StartedAt time.Time
+	EndedAt   *time.Time
+}
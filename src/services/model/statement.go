@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Statement sums up what the courier earned in [From, To) by the ledger: completed orders with their group discounts
+// and adjustments. Penalties and Payouts are negative, Total is what the courier earned in the period.
+type Statement struct {
+	Courier     *Courier
+	From        time.Time
+	To          time.Time
+	Orders      []StatementOrder
+	Adjustments []*LedgerEntry
+	Rating      *int64
+	Earnings    int64
+	Bonuses     int64
+	Penalties   int64
+	Payouts     int64
+	Total       int64
+}
+
+// StatementOrder is a completed order, Discount is the group discount of its cost.
+type StatementOrder struct {
+	Order         *Order
+	EffectiveCost int64
+	Discount      int64
+	Earning       int64
+}
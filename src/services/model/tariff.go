@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Tariff is a version of the order pricing. Tariffs are never changed, a new version is created instead.
+type Tariff struct {
+	Version int64
+	// BasePrice is the base price of regions without a zone price
+	BasePrice             int64
+	ZonePrices            []TariffZonePrice
+	PerKgPrice            int64
+	NarrowWindowMinutes   int
+	NarrowWindowSurcharge int64
+	Surges                []TariffSurge
+	CreatedAt             time.Time
+}
+
+type TariffZonePrice struct {
+	RegionId  int64
+	BasePrice int64
+}
+
+// TariffSurge multiplies prices of orders with the earliest delivery window starting within the interval (HH:MM-HH:MM).
+type TariffSurge struct {
+	Interval   string
+	Multiplier float64
+}
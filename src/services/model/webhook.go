@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Webhook delivery statuses, a dead delivery failed every attempt and is not retried.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook is a subscription of a URL to domain event types, deliveries are signed with the secret.
+type Webhook struct {
+	WebhookId  int64
+	Url        string
+	EventTypes []string
+	Secret     string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// WebhookDelivery is an event sent or to be sent to a webhook, Body is the JSON posted.
+type WebhookDelivery struct {
+	DeliveryId     int64
+	WebhookId      int64
+	EventId        int64
+	EventType      string
+	Body           []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      *string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"Ya.SumSchool23/time_interval"
+	"time"
+)
+
+// lateness returns how many minutes after the promised time the order was completed or, without a promise,
+// after the end of the latest delivery window ended before completion. Completion inside a window or before
+// all of them is on time. Delivery hours are local times of the location on the delivery date.
+func lateness(
+	completed time.Time,
+	promisedBy *time.Time,
+	date time.Time,
+	windows []time_interval.Interval,
+	location *time.Location,
+) int {
+	if promisedBy != nil {
+		if late := int(completed.Sub(*promisedBy) / time.Minute); late > 0 {
+			return late
+		}
+		return 0
+	}
+
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, location)
+	late := 0
+	for _, window := range windows {
+		from := start.Add(time.Duration(window.Start) * time.Minute)
+		to := start.Add(time.Duration(window.End) * time.Minute)
+		if !completed.Before(from) && !completed.After(to) {
+			return 0
+		}
+		if minutes := int(completed.Sub(to) / time.Minute); completed.After(to) && (late == 0 || minutes < late) {
+			late = minutes
+		}
+	}
+	return late
+}
+
+// promisedMinute is the minute of the date the promised time falls on in the location,
+// 0 if it is before the date and nil if after.
+func promisedMinute(promisedBy time.Time, date time.Time, location *time.Location) *int {
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, location)
+	minute := 0
+	if promisedBy.After(start) {
+		minute = int(promisedBy.Sub(start) / time.Minute)
+	}
+	if minute >= 24*60 {
+		return nil
+	}
+	return &minute
+}
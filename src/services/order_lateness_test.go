@@ -0,0 +1,47 @@
+package services
+
+import (
+	"Ya.SumSchool23/time_interval"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLateness(t *testing.T) {
+	windows, err := time_interval.ParseAll([]string{"09:00-10:00", "12:00-13:00"})
+	require.NoError(t, err)
+	at := func(clock string) time.Time {
+		completed, err := time.Parse(time.RFC3339, "2023-05-11T"+clock+":00Z")
+		require.NoError(t, err)
+		return completed
+	}
+	date := time.Date(2023, 5, 11, 0, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 0, lateness(at("08:30"), nil, date, windows, time.UTC))
+	require.Equal(t, 0, lateness(at("09:30"), nil, date, windows, time.UTC))
+	require.Equal(t, 45, lateness(at("10:45"), nil, date, windows, time.UTC))
+	require.Equal(t, 0, lateness(at("12:10"), nil, date, windows, time.UTC))
+	require.Equal(t, 20, lateness(at("13:20"), nil, date, windows, time.UTC))
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	require.Equal(t, 0, lateness(at("06:30"), nil, date, windows, moscow))
+	require.Equal(t, 30, lateness(at("07:30"), nil, date, windows, moscow))
+
+	// delivery hours are on the delivery date
+	require.Equal(t, 2*24*60-3*60-30, lateness(at("09:30").AddDate(0, 0, 2), nil, date, windows, time.UTC), "completed days later")
+	require.Equal(t, 0, lateness(at("13:20").AddDate(0, 0, -1), nil, date, windows, time.UTC), "completed the day before")
+
+	promised := at("09:15")
+	require.Equal(t, 0, lateness(at("09:00"), &promised, date, windows, time.UTC))
+	require.Equal(t, 15, lateness(at("09:30"), &promised, date, windows, time.UTC))
+}
+
+func TestPromisedMinute(t *testing.T) {
+	date := time.Date(2023, 5, 11, 0, 0, 0, 0, time.UTC)
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	require.Equal(t, 0, *promisedMinute(date.Add(-time.Hour), date, time.UTC))
+	require.Equal(t, 9*60+30, *promisedMinute(date.Add(9*time.Hour+30*time.Minute), date, time.UTC))
+	require.Equal(t, 12*60, *promisedMinute(date.Add(9*time.Hour), date, moscow))
+	require.Nil(t, promisedMinute(date.AddDate(0, 0, 1), date, time.UTC))
+}
@@ -1,18 +1,30 @@
 package services
 
 import (
+	cerrors "Ya.SumSchool23/controllers/errors"
 	"Ya.SumSchool23/repositories"
 	"Ya.SumSchool23/services/model"
 	"Ya.SumSchool23/services/service_data"
+	"Ya.SumSchool23/time_interval"
+	"context"
+	"time"
 )
 
 type OrderService struct {
 	orderRepository *repositories.OrderRepository
+	regionService   *RegionService
+	tariffService   *TariffService
+	ledgerService   *LedgerService
+	replanner       *Replanner
 }
 
-func NewOrderService(r *repositories.OrderRepository) *OrderService {
+func NewOrderService(r *repositories.OrderRepository, rs *RegionService, ts *TariffService, ls *LedgerService, rp *Replanner) *OrderService {
 	return &OrderService{
 		orderRepository: r,
+		regionService:   rs,
+		tariffService:   ts,
+		ledgerService:   ls,
+		replanner:       rp,
 	}
 }
 
@@ -20,14 +32,36 @@ func (s *OrderService) GetOrderById(id int64) (*model.Order, error) {
 	return s.orderRepository.GetOrderById(id)
 }
 
-func (s *OrderService) GetOrders(limit, offset int64) ([]*model.Order, error) {
-	return s.orderRepository.GetOrders(limit, offset)
+func (s *OrderService) GetOrders(limit, offset int64, deliveryDate *time.Time) ([]*model.Order, error) {
+	return s.orderRepository.GetOrders(limit, offset, deliveryDate)
 }
 
-func (s *OrderService) CreateOrders(data []service_data.NewOrderData) ([]*model.Order, error) {
+func (s *OrderService) CreateOrders(ctx context.Context, data []service_data.NewOrderData) ([]*model.Order, error) {
 	result := make([]*model.Order, 0)
 
-	orderIds, err := s.orderRepository.CreateOrders(data)
+	regions := make([]int64, len(data))
+	for i := 0; i < len(data); i++ {
+		if err := s.resolveOrderRegion(&data[i]); err != nil {
+			return nil, err
+		}
+		if err := setDeliveryDate(&data[i]); err != nil {
+			return nil, err
+		}
+		regions[i] = data[i].Regions
+	}
+	if err := s.regionService.ValidateRegions(regions); err != nil {
+		return nil, err
+	}
+	// orders without a cost are priced by the current tariff
+	for i := 0; i < len(data); i++ {
+		if data[i].Cost == nil {
+			if err := s.tariffService.PriceOrder(&data[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	orderIds, err := s.orderRepository.CreateOrders(data, actorOf(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -36,15 +70,31 @@ func (s *OrderService) CreateOrders(data []service_data.NewOrderData) ([]*model.
 		if err != nil {
 			return nil, err
 		}
+		recordChange(ctx, model.AuditEntityOrder, id, nil, order)
 		result = append(result, order)
 	}
+	s.replanner.OrdersCreated()
 	return result, nil
 }
 
-func (s *OrderService) CreateCompleteOrder(data []service_data.NewCompleteOrderData) ([]*model.Order, error) {
+func (s *OrderService) CreateCompleteOrder(ctx context.Context, data []service_data.NewCompleteOrderData) ([]*model.Order, error) {
 	result := make([]*model.Order, 0)
 
-	orderIds, err := s.orderRepository.CreateCompleteOrder(data)
+	previous, err := s.setLateness(data)
+	if err != nil {
+		return nil, err
+	}
+	before := make(map[int64]*model.Order, len(previous))
+	for _, order := range previous {
+		before[order.OrderId] = order
+	}
+
+	entries, err := s.ledgerService.CompletionEntries(data, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	orderIds, err := s.orderRepository.CreateCompleteOrder(data, entries, actorOf(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +103,147 @@ func (s *OrderService) CreateCompleteOrder(data []service_data.NewCompleteOrderD
 		if err != nil {
 			return nil, err
 		}
+		// completing an order again changes nothing
+		if previous := before[id]; previous != nil && previous.CompletedTime == nil {
+			recordChange(ctx, model.AuditEntityOrder, id, previous, order)
+		}
 		result = append(result, order)
 	}
 	return result, nil
 }
+
+// CreateFeedback stores the customer feedback of a completed order, the feedback is about the courier that completed it.
+func (s *OrderService) CreateFeedback(data service_data.NewOrderFeedbackData) (*model.OrderFeedback, error) {
+	if data.Score < 1 || data.Score > 5 {
+		return nil, cerrors.BadRequest.Newf("feedback score must be within [1, 5], got %d", data.Score)
+	}
+	order, err := s.orderRepository.GetOrderById(data.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.CompletedTime == nil || order.CompletedCourierId == nil {
+		return nil, cerrors.BadRequest.Newf("order with id = '%v' is not completed", data.OrderId)
+	}
+
+	if err = s.orderRepository.CreateFeedback(data, *order.CompletedCourierId); err != nil {
+		return nil, err
+	}
+	return s.orderRepository.GetFeedback(data.OrderId)
+}
+
+func (s *OrderService) GetFeedback(orderId int64) (*model.OrderFeedback, error) {
+	if _, err := s.orderRepository.GetOrderById(orderId); err != nil {
+		return nil, err
+	}
+	return s.orderRepository.GetFeedback(orderId)
+}
+
+// GetHistory returns the events of the order, oldest first.
+func (s *OrderService) GetHistory(orderId int64) ([]*model.OrderEvent, error) {
+	if _, err := s.orderRepository.GetOrderById(orderId); err != nil {
+		return nil, err
+	}
+	return s.orderRepository.GetOrderEvents(orderId)
+}
+
+func (s *OrderService) GetLatestEvent(orderId int64) (*model.OrderEvent, error) {
+	return s.orderRepository.GetLatestOrderEvent(orderId)
+}
+
+// GetLateRates counts orders completed in [from, to) and late ones per courier and per region.
+func (s *OrderService) GetLateRates(from, to time.Time) ([]*model.LateRate, []*model.LateRate, error) {
+	couriers, err := s.orderRepository.GetCourierLateRates(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	regions, err := s.orderRepository.GetRegionLateRates(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return couriers, regions, nil
+}
+
+// setLateness calculates the lateness of orders being completed and returns the orders as they were before.
+// Orders without a promised time and with unparsable delivery hours get none, unknown orders are reported by the repository.
+func (s *OrderService) setLateness(data []service_data.NewCompleteOrderData) ([]*model.Order, error) {
+	ids := make([]int64, len(data))
+	for i := 0; i < len(data); i++ {
+		ids[i] = data[i].OrderId
+	}
+	orders, err := s.orderRepository.GetOrdersByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]*model.Order, len(orders))
+	regions := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		byId[order.OrderId] = order
+		regions = append(regions, order.Regions)
+	}
+	locations, err := s.regionService.GetLocations(regions)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(data); i++ {
+		completed, err := time.Parse(time.RFC3339, data[i].CompleteTime)
+		if err != nil {
+			return nil, cerrors.BadRequest.Wrapf(err, "cannot parse complete time of order '%v', got '%s'",
+				data[i].OrderId, data[i].CompleteTime)
+		}
+		order, ok := byId[data[i].OrderId]
+		if !ok {
+			continue
+		}
+		windows, err := time_interval.ParseAll(order.DeliveryHours)
+		if err != nil && order.PromisedBy == nil {
+			continue
+		}
+		location, ok := locations[order.Regions]
+		if !ok {
+			location = time.UTC
+		}
+		late := lateness(completed, order.PromisedBy, order.DeliveryDate, windows, location)
+		data[i].LatenessMinutes = &late
+	}
+	return orders, nil
+}
+
+// setDeliveryDate defaults the delivery date to today, orders cannot be created for past dates.
+func setDeliveryDate(data *service_data.NewOrderData) error {
+	created := today()
+	if data.DeliveryDate == nil {
+		data.DeliveryDate = &created
+		return nil
+	}
+	if data.DeliveryDate.Before(created) {
+		return cerrors.BadRequest.Newf("delivery date '%s' is in the past", data.DeliveryDate.Format(dateLayout))
+	}
+	return nil
+}
+
+// resolveOrderRegion sets the order region from its coordinates when they are given.
+func (s *OrderService) resolveOrderRegion(data *service_data.NewOrderData) error {
+	if data.Lat == nil && data.Lon == nil {
+		if data.Regions == 0 {
+			return cerrors.BadRequest.New("order must have either regions or lat and lon")
+		}
+		return nil
+	}
+	if data.Lat == nil || data.Lon == nil {
+		return cerrors.BadRequest.New("order must have both lat and lon")
+	}
+	if *data.Lat < -90 || *data.Lat > 90 || *data.Lon < -180 || *data.Lon > 180 {
+		return cerrors.BadRequest.Newf("invalid order coordinates (%v, %v)", *data.Lat, *data.Lon)
+	}
+
+	region, err := s.regionService.ResolveRegion(*data.Lat, *data.Lon)
+	if err != nil {
+		return err
+	}
+	if data.Regions != 0 && data.Regions != region {
+		return cerrors.BadRequest.Newf("order coordinates are in region '%v', not in '%v'", region, data.Regions)
+	}
+	data.Regions = region
+	return nil
+}
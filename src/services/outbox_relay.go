@@ -0,0 +1,104 @@
+package services
+
+import (
+	"Ya.SumSchool23/outbox"
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/webhooks"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Relays of the outbox, each goes through the outbox on its own.
+const (
+	// OutboxRelayPublisher is the relay of the configured outbox publisher.
+	OutboxRelayPublisher = "publisher"
+	// OutboxRelayWebhooks enqueues deliveries to webhook subscriptions.
+	OutboxRelayWebhooks = "webhooks"
+)
+
+// OutboxRelayConfig configures publishing of the outbox.
+type OutboxRelayConfig struct {
+	// Interval is the wait after the outbox is drained or publishing failed.
+	Interval  time.Duration
+	BatchSize int
+	// Lease is how long an instance has the relay to itself to publish a batch, it must outlast publishing a batch.
+	Lease time.Duration
+	// A failed event is retried after BaseBackoff doubled with every failed attempt up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// OutboxRelay publishes the domain events of the outbox in order, at least once.
+// Only one instance of a relay publishes at a time.
+type OutboxRelay struct {
+	outboxRepository *repositories.OutboxRepository
+	name             string
+	publisher        outbox.Publisher
+	config           OutboxRelayConfig
+}
+
+func NewOutboxRelay(r *repositories.OutboxRepository, name string, publisher outbox.Publisher, config OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepository: r,
+		name:             name,
+		publisher:        publisher,
+		config:           config,
+	}
+}
+
+// Run publishes pending events forever, a failed event is retried after its backoff before any later event.
+func (r *OutboxRelay) Run() {
+	for {
+		published, err := r.publishBatch()
+		if err != nil {
+			log.Printf("outbox relay '%s': publishing failed: %s", r.name, err.Error())
+		}
+		if err != nil || published < r.config.BatchSize {
+			time.Sleep(r.config.Interval)
+		}
+	}
+}
+
+// publishBatch publishes the next batch of events outside of any transaction and stores how far it got.
+// Publishing stops at the first failure to keep the order. The failed event is not skipped: the relay keeps
+// its position and stays leased for the backoff of the attempt, so every event is published at least once.
+func (r *OutboxRelay) publishBatch() (int, error) {
+	state, err := r.outboxRepository.ClaimRelay(r.name, r.config.Lease)
+	if err != nil || state == nil {
+		return 0, err
+	}
+	events, err := r.outboxRepository.GetRelayEvents(state.OutboxPosition, r.config.BatchSize)
+	if err != nil {
+		if releaseErr := r.outboxRepository.ReleaseRelay(state, 0); releaseErr != nil {
+			log.Printf("outbox relay '%s': release failed: %s", r.name, releaseErr.Error())
+		}
+		return 0, err
+	}
+
+	published := 0
+	var publishErr error
+	var backoff time.Duration
+	for _, event := range events {
+		if publishErr = r.publisher.Publish(event); publishErr != nil {
+			lastError := publishErr.Error()
+			state.Attempts++
+			state.LastError = &lastError
+			backoff = r.backoff(state.Attempts)
+			publishErr = fmt.Errorf("event %d failed %d times, retrying in %s: %w", event.Id, state.Attempts, backoff, publishErr)
+			break
+		}
+		state.TxId, state.EventId, state.Attempts, state.LastError = event.TxId, event.Id, 0, nil
+		published++
+	}
+
+	if err = r.outboxRepository.ReleaseRelay(state, backoff); err != nil {
+		return published, err
+	}
+	return published, publishErr
+}
+
+// backoff is the wait after the failed attempt, attempts are counted from 1.
+func (r *OutboxRelay) backoff(attempt int) time.Duration {
+	return webhooks.RetryPolicy{BaseBackoff: r.config.BaseBackoff, MaxBackoff: r.config.MaxBackoff}.Backoff(attempt)
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/geo"
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"sync"
+	"time"
+)
+
+type RegionService struct {
+	regionRepository *repositories.RegionRepository
+
+	// index of region boundaries, built on first lookup and dropped on every region change
+	index      *geo.Index
+	indexMutex sync.Mutex
+}
+
+func NewRegionService(r *repositories.RegionRepository) *RegionService {
+	return &RegionService{
+		regionRepository: r,
+	}
+}
+
+func (s *RegionService) GetRegions(limit, offset int64) ([]*model.Region, error) {
+	return s.regionRepository.GetRegions(limit, offset)
+}
+
+func (s *RegionService) GetRegionById(id int64) (*model.Region, error) {
+	return s.regionRepository.GetRegionById(id)
+}
+
+// GetRegionsWithBoundary returns regions that have a boundary polygon.
+func (s *RegionService) GetRegionsWithBoundary() ([]*model.Region, error) {
+	return s.regionRepository.GetRegionsWithBoundary()
+}
+
+func (s *RegionService) CreateRegion(data service_data.NewRegionData) (*model.Region, error) {
+	if err := validateRegionData(data); err != nil {
+		return nil, err
+	}
+	if err := s.regionRepository.CreateRegion(data); err != nil {
+		return nil, err
+	}
+	s.invalidateIndex()
+	return s.regionRepository.GetRegionById(data.RegionId)
+}
+
+func (s *RegionService) UpdateRegion(data service_data.NewRegionData) (*model.Region, error) {
+	if err := validateRegionData(data); err != nil {
+		return nil, err
+	}
+	if err := s.regionRepository.UpdateRegion(data); err != nil {
+		return nil, err
+	}
+	s.invalidateIndex()
+	return s.regionRepository.GetRegionById(data.RegionId)
+}
+
+func (s *RegionService) DeleteRegion(id int64) error {
+	if err := s.regionRepository.DeleteRegion(id); err != nil {
+		return err
+	}
+	s.invalidateIndex()
+	return nil
+}
+
+// ResolveRegion returns the active region whose boundary contains the point.
+func (s *RegionService) ResolveRegion(lat, lon float64) (int64, error) {
+	index, err := s.getIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	id, ok := index.Locate(geo.Point{Lon: lon, Lat: lat})
+	if !ok {
+		return 0, cerrors.BadRequest.Newf("no region contains point (%v, %v)", lat, lon)
+	}
+	return id, nil
+}
+
+func (s *RegionService) getIndex() (*geo.Index, error) {
+	s.indexMutex.Lock()
+	defer s.indexMutex.Unlock()
+
+	if s.index != nil {
+		return s.index, nil
+	}
+
+	regions, err := s.regionRepository.GetRegionsWithBoundary()
+	if err != nil {
+		return nil, err
+	}
+	index := geo.NewIndex()
+	for _, region := range regions {
+		if !region.Active {
+			continue
+		}
+		geometry, err := geo.ParseGeometry(region.Boundary)
+		if err != nil {
+			return nil, cerrors.Wrapf(err, "invalid boundary of region '%v'", region.RegionId)
+		}
+		index.Add(region.RegionId, geometry)
+	}
+	s.index = index
+	return index, nil
+}
+
+func (s *RegionService) invalidateIndex() {
+	s.indexMutex.Lock()
+	defer s.indexMutex.Unlock()
+	s.index = nil
+}
+
+func validateRegionData(data service_data.NewRegionData) error {
+	if _, err := time.LoadLocation(data.TimeZone); err != nil {
+		return cerrors.BadRequest.Wrapf(err, "unknown time zone '%s'", data.TimeZone)
+	}
+	if len(data.Boundary) > 0 {
+		if _, err := geo.ParseGeometry(data.Boundary); err != nil {
+			return cerrors.BadRequest.Wrap(err, "invalid region boundary")
+		}
+	}
+	return nil
+}
+
+// GetLocations returns the time zones of the regions, unknown regions are missing from the map.
+func (s *RegionService) GetLocations(ids []int64) (map[int64]*time.Location, error) {
+	regions, err := s.regionRepository.GetRegionsByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[int64]*time.Location, len(regions))
+	for _, region := range regions {
+		location, err := time.LoadLocation(region.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		locations[region.RegionId] = location
+	}
+	return locations, nil
+}
+
+// ValidateRegions checks that all regions exist and are active.
+func (s *RegionService) ValidateRegions(ids []int64) error {
+	regions, err := s.regionRepository.GetRegionsByIds(ids)
+	if err != nil {
+		return err
+	}
+
+	active := make(map[int64]bool, len(regions))
+	for _, region := range regions {
+		active[region.RegionId] = region.Active
+	}
+	for _, id := range ids {
+		if !active[id] {
+			return cerrors.BadRequest.Newf("region '%v' does not exist or is not active", id)
+		}
+	}
+	return nil
+}
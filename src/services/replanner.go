@@ -0,0 +1,68 @@
+package services
+
+import (
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	ReplanTriggerOff      = "off"
+	ReplanTriggerBatch    = "batch"
+	ReplanTriggerDebounce = "debounce"
+)
+
+// ReplanConfig configures automatic incremental re-planning of today's assignment.
+type ReplanConfig struct {
+	// Trigger is ReplanTriggerOff, ReplanTriggerBatch to re-plan after every order batch
+	// or ReplanTriggerDebounce to re-plan once no batch came for Debounce.
+	Trigger  string
+	Debounce time.Duration
+}
+
+// Replanner runs incremental assignment for today when new orders arrive.
+type Replanner struct {
+	config ReplanConfig
+	replan func() error
+
+	timerMutex sync.Mutex
+	timer      *time.Timer
+}
+
+func NewReplanner(s *AssignmentService, config ReplanConfig) *Replanner {
+	return newReplanner(config, func() error {
+		_, err := s.Assign(context.Background(), service_data.NewAssignData{Date: today(), Incremental: true, Actor: model.ActorReplanner})
+		return err
+	})
+}
+
+func newReplanner(config ReplanConfig, replan func() error) *Replanner {
+	return &Replanner{
+		config: config,
+		replan: replan,
+	}
+}
+
+// OrdersCreated is called after a batch of orders is stored.
+func (r *Replanner) OrdersCreated() {
+	switch r.config.Trigger {
+	case ReplanTriggerBatch:
+		go r.run()
+	case ReplanTriggerDebounce:
+		r.timerMutex.Lock()
+		defer r.timerMutex.Unlock()
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+		r.timer = time.AfterFunc(r.config.Debounce, r.run)
+	}
+}
+
+func (r *Replanner) run() {
+	if err := r.replan(); err != nil {
+		log.Printf("incremental re-planning failed: %s", err.Error())
+	}
+}
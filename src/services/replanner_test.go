@@ -0,0 +1,39 @@
+package services
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplannerDebounce(t *testing.T) {
+	var runs int32
+	replanner := newReplanner(ReplanConfig{Trigger: ReplanTriggerDebounce, Debounce: 50 * time.Millisecond}, func() error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		replanner.OrdersCreated()
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), atomic.LoadInt32(&runs), "batches within the debounce interval must re-plan once")
+}
+
+func TestReplannerBatchAndOff(t *testing.T) {
+	var runs int32
+	replan := func() error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+
+	newReplanner(ReplanConfig{Trigger: ReplanTriggerOff}, replan).OrdersCreated()
+	batch := newReplanner(ReplanConfig{Trigger: ReplanTriggerBatch}, replan)
+	batch.OrdersCreated()
+	batch.OrdersCreated()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 }, time.Second, 10*time.Millisecond)
+}
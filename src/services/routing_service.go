@@ -0,0 +1,46 @@
+package services
+
+import (
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/routing"
+	"Ya.SumSchool23/services/model"
+)
+
+// RoutingService estimates delivery durations and plans stops over the region graph.
+type RoutingService struct {
+	regionRepository *repositories.RegionRepository
+	estimator        *routing.Estimator
+	sequencer        *routing.Sequencer
+}
+
+func NewRoutingService(r *repositories.RegionRepository, graph *routing.Graph) *RoutingService {
+	estimator := routing.NewEstimator(graph)
+	return &RoutingService{
+		regionRepository: r,
+		estimator:        estimator,
+		sequencer:        routing.NewSequencer(estimator),
+	}
+}
+
+// BuildRegionGraph builds the region graph from the region_edges table rows.
+func BuildRegionGraph(edges []*model.RegionEdge) (*routing.Graph, error) {
+	graph := routing.NewGraph()
+	for _, edge := range edges {
+		if err := graph.AddEdge(edge.FromRegion, edge.ToRegion, edge.CourierType, edge.Minutes); err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+func (s *RoutingService) GetNeighbors(regionId int64) ([]routing.Edge, error) {
+	if _, err := s.regionRepository.GetRegionById(regionId); err != nil {
+		return nil, err
+	}
+	return s.estimator.Graph().Neighbors(regionId), nil
+}
+
+// Sequencer returns the stop sequencer used to plan group orders.
+func (s *RoutingService) Sequencer() *routing.Sequencer {
+	return s.sequencer
+}
@@ -1,5 +1,10 @@
 package service_data
 
+import (
+	"Ya.SumSchool23/services/model"
+	"time"
+)
+
 type NewCourierData struct {
 	CourierType  string
 	Regions      []int64
@@ -9,19 +14,64 @@ type NewCourierData struct {
 type NewOrderData struct {
 	Weight        float64
 	Regions       int64
+	Lat           *float64
+	Lon           *float64
 	DeliveryHours []string
-	Cost          int64
+	Cost          *int64
+	Priority      int
+	PromisedBy    *time.Time
+	// DeliveryDate is the creation date if not set
+	DeliveryDate *time.Time
+	// TariffVersion is set when the cost is calculated by a tariff
+	TariffVersion *int64
+}
+
+type NewTariffData struct {
+	BasePrice             int64
+	ZonePrices            []model.TariffZonePrice
+	PerKgPrice            int64
+	NarrowWindowMinutes   int
+	NarrowWindowSurcharge int64
+	Surges                []model.TariffSurge
 }
 
 type NewCompleteOrderData struct {
 	CourierId    int64
 	OrderId      int64
 	CompleteTime string
+	// LatenessMinutes is calculated by the order service
+	LatenessMinutes *int
 }
 
 type NewOrderAssignResponseData struct {
 	Date     string
 	Couriers []NewCouriersGroupOrdersData
+	Strategy string
+	Metrics  *NewAssignmentMetricsData
+}
+
+// NewAssignData describes an assignment run, empty Strategy and Fairness mean the configured ones.
+type NewAssignData struct {
+	Date        time.Time
+	Strategy    string
+	Incremental bool
+	Fairness    string
+	Actor       string
+}
+
+type NewAssignmentMetricsData struct {
+	DeliveredCost int64
+	Assigned      int
+	Unassigned    int
+	Utilization   float64
+	Fairness      []NewTypeFairnessData
+}
+
+type NewTypeFairnessData struct {
+	CourierType  string
+	Couriers     int
+	EarningsGini float64
+	HoursGini    float64
 }
 
 type NewGroupOrdersData struct {
@@ -41,4 +91,108 @@ type NewOrderDtoData struct {
 	DeliveryHours []string
 	Cost          int64
 	CompletedTime *string
+	// PlannedArrival is the planned delivery time (HH:MM) once the group is sequenced
+	PlannedArrival *string
+}
+
+type NewCourierScheduleData struct {
+	Weekday      int
+	WorkingHours []string
+}
+
+type NewScheduleOverrideData struct {
+	CourierId    int64
+	Date         time.Time
+	Kind         string
+	WorkingHours []string
+	Comment      *string
+}
+
+type NewRegionData struct {
+	RegionId int64
+	Name     string
+	TimeZone string
+	Active   bool
+	Boundary []byte
+}
+
+// NewAssignmentPlanData is a change of the stored assignment of a date.
+type NewAssignmentPlanData struct {
+	Date     time.Time
+	Strategy string
+	// ReleasedGroupIds are group orders deleted with their orders returned to the pool
+	ReleasedGroupIds []int64
+	// ReleasedOrderIds are orders taken out of their group orders
+	ReleasedOrderIds []int64
+	Groups           []NewGroupOrderData
+	// PinnedOrderIds are orders pinned to the courier of their group order
+	PinnedOrderIds []int64
+	Rejections     []*model.AssignmentRejection
+	// Actor requested the plan, it is recorded in the order history
+	Actor string
+}
+
+// NewGroupOrderData is a planned group order, GroupOrderId is 0 for a new group.
+type NewGroupOrderData struct {
+	GroupOrderId int64
+	CourierId    int64
+	StartMinute  int
+	FinishMinute int
+	Stops        []NewGroupOrderStopData
+}
+
+type NewGroupOrderStopData struct {
+	OrderId        int64
+	PlannedArrival string
+}
+
+// NewWhatIfData holds hypothetical couriers and orders added to an assignment preview.
+type NewWhatIfData struct {
+	Couriers []NewCourierData
+	Orders   []NewOrderData
+}
+
+type NewLedgerEntryData struct {
+	CourierId int64
+	Kind      string
+	Reason    string
+	Amount    int64
+	OrderId   *int64
+	Comment   *string
+	Date      time.Time
+}
+
+type NewOrderFeedbackData struct {
+	OrderId int64
+	Score   int
+	Comment *string
+}
+
+// AuditFilterData selects audit entries, empty fields match every entry. Time range is [From, To).
+type AuditFilterData struct {
+	Actor      string
+	EntityType string
+	EntityId   *int64
+	From       *time.Time
+	To         *time.Time
+	Limit      int64
+	Offset     int64
+}
+
+type NewWebhookData struct {
+	WebhookId  int64
+	Url        string
+	EventTypes []string
+	Secret     string
+	Active     bool
+}
+
+// NewDeliveryAttemptData is the state of a webhook delivery after an attempt.
+type NewDeliveryAttemptData struct {
+	DeliveryId     int64
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      *string
 }
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"time"
+)
+
+type StatementService struct {
+	courierService   *CourierService
+	orderRepository  *repositories.OrderRepository
+	ledgerRepository *repositories.LedgerRepository
+}
+
+func NewStatementService(cs *CourierService, or *repositories.OrderRepository, lr *repositories.LedgerRepository) *StatementService {
+	return &StatementService{
+		courierService:   cs,
+		orderRepository:  or,
+		ledgerRepository: lr,
+	}
+}
+
+// GetStatement builds the statement of [from, to) from the courier ledger: the earnings of completed orders
+// and adjustments dated in the period, so it matches what was paid out. The rating is the one of the courier meta-info.
+func (s *StatementService) GetStatement(courierId int64, from, to time.Time) (*model.Statement, error) {
+	metaInfo, err := s.courierService.GetCourierMetaInfo(courierId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	earnings, err := s.ledgerRepository.GetEarnings(courierId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := s.ledgerRepository.GetAdjustments(courierId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	orderIds := make([]int64, len(earnings))
+	for i, entry := range earnings {
+		orderIds[i] = *entry.OrderId
+	}
+	orders, err := s.orderRepository.GetOrdersByIds(orderIds)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]*model.Order, len(orders))
+	for _, order := range orders {
+		byId[order.OrderId] = order
+	}
+
+	statement := &model.Statement{
+		Courier:     metaInfo.Courier,
+		From:        from,
+		To:          to,
+		Orders:      make([]model.StatementOrder, len(earnings)),
+		Adjustments: adjustments,
+		Rating:      metaInfo.Rating,
+	}
+
+	coefficient := model.CourierTypeProfiles[metaInfo.Courier.CourierType].EarningsCoefficient
+	for i, entry := range earnings {
+		order := byId[*entry.OrderId]
+		effectiveCost := entry.Amount / coefficient
+		statement.Orders[i] = model.StatementOrder{
+			Order:         order,
+			EffectiveCost: effectiveCost,
+			Discount:      order.Cost - effectiveCost,
+			Earning:       entry.Amount,
+		}
+		statement.Earnings += entry.Amount
+	}
+	for _, entry := range adjustments {
+		switch entry.Kind {
+		case model.LedgerKindBonus:
+			statement.Bonuses += entry.Amount
+		case model.LedgerKindPenalty:
+			statement.Penalties += entry.Amount
+		case model.LedgerKindPayout:
+			statement.Payouts += entry.Amount
+		}
+	}
+	statement.Total = statement.Earnings + statement.Bonuses + statement.Penalties
+	return statement, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/pricing"
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"Ya.SumSchool23/time_interval"
+)
+
+type TariffService struct {
+	tariffRepository *repositories.TariffRepository
+	regionService    *RegionService
+}
+
+func NewTariffService(r *repositories.TariffRepository, rs *RegionService) *TariffService {
+	return &TariffService{
+		tariffRepository: r,
+		regionService:    rs,
+	}
+}
+
+func (s *TariffService) GetTariffs(limit, offset int64) ([]*model.Tariff, error) {
+	return s.tariffRepository.GetTariffs(limit, offset)
+}
+
+func (s *TariffService) GetTariffByVersion(version int64) (*model.Tariff, error) {
+	return s.tariffRepository.GetTariffByVersion(version)
+}
+
+// GetCurrentTariff returns the tariff new orders are priced by.
+func (s *TariffService) GetCurrentTariff() (*model.Tariff, error) {
+	tariff, err := s.tariffRepository.GetCurrentTariff()
+	if err != nil {
+		return nil, err
+	}
+	if tariff == nil {
+		return nil, cerrors.NotFound.New("no tariff is set")
+	}
+	return tariff, nil
+}
+
+// CreateTariff stores a new tariff version, it becomes the current one.
+func (s *TariffService) CreateTariff(data service_data.NewTariffData) (*model.Tariff, error) {
+	if err := s.validateTariffData(data); err != nil {
+		return nil, err
+	}
+	version, err := s.tariffRepository.CreateTariff(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.tariffRepository.GetTariffByVersion(version)
+}
+
+// PriceOrder sets the cost of the order calculated by the current tariff and the tariff version.
+func (s *TariffService) PriceOrder(data *service_data.NewOrderData) error {
+	tariff, err := s.tariffRepository.GetCurrentTariff()
+	if err != nil {
+		return err
+	}
+	if tariff == nil {
+		return cerrors.BadRequest.New("order cost is required while no tariff is set")
+	}
+	windows, err := time_interval.ParseAll(data.DeliveryHours)
+	if err != nil {
+		return cerrors.BadRequest.Wrap(err, "cannot price order with invalid delivery hours")
+	}
+
+	engine, err := toPricingTariff(tariff)
+	if err != nil {
+		return err
+	}
+	cost := engine.Price(data.Weight, data.Regions, windows)
+	data.Cost = &cost
+	data.TariffVersion = &tariff.Version
+	return nil
+}
+
+func (s *TariffService) validateTariffData(data service_data.NewTariffData) error {
+	if data.BasePrice < 0 || data.PerKgPrice < 0 || data.NarrowWindowMinutes < 0 || data.NarrowWindowSurcharge < 0 {
+		return cerrors.BadRequest.New("tariff prices and minutes must not be negative")
+	}
+
+	regions := make([]int64, len(data.ZonePrices))
+	seen := make(map[int64]bool, len(data.ZonePrices))
+	for i, zone := range data.ZonePrices {
+		if zone.BasePrice < 0 {
+			return cerrors.BadRequest.Newf("base price of region '%v' must not be negative", zone.RegionId)
+		}
+		if seen[zone.RegionId] {
+			return cerrors.BadRequest.Newf("region '%v' has more than one base price", zone.RegionId)
+		}
+		seen[zone.RegionId] = true
+		regions[i] = zone.RegionId
+	}
+	if err := s.regionService.ValidateRegions(regions); err != nil {
+		return err
+	}
+
+	intervals := make([]time_interval.Interval, len(data.Surges))
+	for i, surge := range data.Surges {
+		interval, err := time_interval.Parse(surge.Interval)
+		if err != nil {
+			return cerrors.BadRequest.Wrap(err, "invalid surge interval")
+		}
+		if surge.Multiplier <= 0 {
+			return cerrors.BadRequest.Newf("multiplier of surge '%s' must be positive", surge.Interval)
+		}
+		for _, other := range intervals[:i] {
+			if interval.Overlaps(other) {
+				return cerrors.BadRequest.Newf("surge '%s' overlaps surge '%s'", surge.Interval, other)
+			}
+		}
+		intervals[i] = interval
+	}
+	return nil
+}
+
+func toPricingTariff(tariff *model.Tariff) (pricing.Tariff, error) {
+	result := pricing.Tariff{
+		BasePrice:             tariff.BasePrice,
+		ZonePrices:            make(map[int64]int64, len(tariff.ZonePrices)),
+		PerKgPrice:            tariff.PerKgPrice,
+		NarrowWindowMinutes:   tariff.NarrowWindowMinutes,
+		NarrowWindowSurcharge: tariff.NarrowWindowSurcharge,
+		Surges:                make([]pricing.Surge, len(tariff.Surges)),
+	}
+	for _, zone := range tariff.ZonePrices {
+		result.ZonePrices[zone.RegionId] = zone.BasePrice
+	}
+	for i, surge := range tariff.Surges {
+		interval, err := time_interval.Parse(surge.Interval)
+		if err != nil {
+			return pricing.Tariff{}, err
+		}
+		result.Surges[i] = pricing.Surge{Interval: interval, Multiplier: surge.Multiplier}
+	}
+	return result, nil
+}
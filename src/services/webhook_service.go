@@ -0,0 +1,180 @@
+package services
+
+import (
+	cerrors "Ya.SumSchool23/controllers/errors"
+	"Ya.SumSchool23/outbox"
+	"Ya.SumSchool23/repositories"
+	"Ya.SumSchool23/services/model"
+	"Ya.SumSchool23/services/service_data"
+	"Ya.SumSchool23/webhooks"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// WebhookConfig configures webhook deliveries.
+type WebhookConfig struct {
+	Retry webhooks.RetryPolicy
+	// Timeout of a single delivery request
+	Timeout time.Duration
+	// Interval is the wait after no delivery was due
+	Interval  time.Duration
+	BatchSize int
+}
+
+// webhookEventTypes are the event types a webhook can subscribe to.
+var webhookEventTypes = map[string]bool{
+	model.EventOrderCreated:           true,
+	model.EventOrderAssigned:          true,
+	model.EventOrderUnassigned:        true,
+	model.EventOrderCompleted:         true,
+	model.EventCourierCreated:         true,
+	model.EventCourierUpdated:         true,
+	model.EventCourierScheduleUpdated: true,
+	model.EventCourierShiftStarted:    true,
+	model.EventCourierShiftEnded:      true,
+}
+
+type WebhookService struct {
+	webhookRepository *repositories.WebhookRepository
+	sender            *webhooks.Sender
+	config            WebhookConfig
+}
+
+func NewWebhookService(r *repositories.WebhookRepository, config WebhookConfig) *WebhookService {
+	return &WebhookService{
+		webhookRepository: r,
+		sender:            webhooks.NewSender(config.Timeout, config.Retry),
+		config:            config,
+	}
+}
+
+// CreateWebhook subscribes the URL to the event types, a secret is generated if none is given.
+func (s *WebhookService) CreateWebhook(data service_data.NewWebhookData) (*model.Webhook, error) {
+	if err := validateEventTypes(data.EventTypes); err != nil {
+		return nil, err
+	}
+	if data.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		data.Secret = hex.EncodeToString(secret)
+	}
+
+	id, err := s.webhookRepository.CreateWebhook(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.webhookRepository.GetWebhookById(id)
+}
+
+func (s *WebhookService) GetWebhooks(limit, offset int64) ([]*model.Webhook, error) {
+	return s.webhookRepository.GetWebhooks(limit, offset)
+}
+
+func (s *WebhookService) GetWebhookById(id int64) (*model.Webhook, error) {
+	return s.webhookRepository.GetWebhookById(id)
+}
+
+func (s *WebhookService) UpdateWebhook(data service_data.NewWebhookData) (*model.Webhook, error) {
+	if err := validateEventTypes(data.EventTypes); err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepository.UpdateWebhook(data); err != nil {
+		return nil, err
+	}
+	return s.webhookRepository.GetWebhookById(data.WebhookId)
+}
+
+func (s *WebhookService) DeleteWebhook(id int64) error {
+	return s.webhookRepository.DeleteWebhook(id)
+}
+
+func (s *WebhookService) GetDeliveries(webhookId int64, status string, limit, offset int64) ([]*model.WebhookDelivery, error) {
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		return nil, cerrors.BadRequest.Newf("unknown delivery status '%s'", status)
+	}
+	if _, err := s.webhookRepository.GetWebhookById(webhookId); err != nil {
+		return nil, err
+	}
+	return s.webhookRepository.GetDeliveries(webhookId, status, limit, offset)
+}
+
+// Publish enqueues a delivery of the event to every webhook subscribed to it, it is the outbox publisher of webhooks.
+func (s *WebhookService) Publish(event *model.OutboxEvent) error {
+	body, err := json.Marshal(outbox.NewMessage(event))
+	if err != nil {
+		return err
+	}
+	return s.webhookRepository.EnqueueDeliveries(event, body)
+}
+
+// RunDeliveries sends due deliveries forever.
+func (s *WebhookService) RunDeliveries() {
+	for {
+		sent, err := s.deliverDue()
+		if err != nil {
+			log.Printf("webhook deliveries failed: %s", err.Error())
+		}
+		if err != nil || sent < s.config.BatchSize {
+			time.Sleep(s.config.Interval)
+		}
+	}
+}
+
+// deliverDue makes an attempt of every claimed due delivery and records its outcome.
+func (s *WebhookService) deliverDue() (int, error) {
+	// a claimed delivery is not due again before every delivery of the batch could time out
+	lease := s.config.Timeout * time.Duration(s.config.BatchSize+1)
+	deliveries, err := s.webhookRepository.ClaimDueDeliveries(s.config.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	subscribers := make(map[int64]*model.Webhook)
+	for _, delivery := range deliveries {
+		webhook, ok := subscribers[delivery.WebhookId]
+		if !ok {
+			webhook, err = s.webhookRepository.GetWebhookById(delivery.WebhookId)
+			if err != nil && cerrors.GetType(err) != cerrors.NotFound {
+				return 0, err
+			}
+			subscribers[delivery.WebhookId] = webhook
+		}
+		// the webhook is deleted with its deliveries
+		if webhook == nil {
+			continue
+		}
+
+		outcome := s.sender.Deliver(webhook, delivery, time.Now())
+		err = s.webhookRepository.RecordAttempt(service_data.NewDeliveryAttemptData{
+			DeliveryId:     delivery.DeliveryId,
+			Status:         outcome.Status,
+			Attempts:       outcome.Attempts,
+			NextAttemptAt:  outcome.NextAttemptAt,
+			LastStatusCode: outcome.LastStatusCode,
+			LastError:      outcome.LastError,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return cerrors.BadRequest.New("a webhook needs at least one event type")
+	}
+	for _, eventType := range eventTypes {
+		if !webhookEventTypes[eventType] {
+			return cerrors.BadRequest.Newf("unknown event type '%s'", eventType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package time_interval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const layout = "15:04"
+
+// Interval is a time of day range, stored as minutes since midnight.
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Parse parses an "HH:MM-HH:MM" interval.
+func Parse(str string) (Interval, error) {
+	left, right, found := strings.Cut(str, "-")
+	if !found {
+		return Interval{}, fmt.Errorf("interval '%s' must be in HH:MM-HH:MM format", str)
+	}
+
+	start, err := time.Parse(layout, left)
+	if err != nil {
+		return Interval{}, fmt.Errorf("cannot parse interval start '%s': %w", left, err)
+	}
+	end, err := time.Parse(layout, right)
+	if err != nil {
+		return Interval{}, fmt.Errorf("cannot parse interval end '%s': %w", right, err)
+	}
+
+	interval := Interval{
+		Start: start.Hour()*60 + start.Minute(),
+		End:   end.Hour()*60 + end.Minute(),
+	}
+	if interval.End <= interval.Start {
+		return Interval{}, fmt.Errorf("interval '%s' must end after it starts", str)
+	}
+	return interval, nil
+}
+
+func ParseAll(strs []string) ([]Interval, error) {
+	intervals := make([]Interval, len(strs))
+	for i, str := range strs {
+		interval, err := Parse(str)
+		if err != nil {
+			return nil, err
+		}
+		intervals[i] = interval
+	}
+	return intervals, nil
+}
+
+func (i Interval) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", i.Start/60, i.Start%60, i.End/60, i.End%60)
+}
+
+func (i Interval) Minutes() int {
+	return i.End - i.Start
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start < other.End && other.Start < i.End
+}
+
+// Intersect returns the common part of two intervals, the second result is false if there is none.
+func (i Interval) Intersect(other Interval) (Interval, bool) {
+	if !i.Overlaps(other) {
+		return Interval{}, false
+	}
+	result := Interval{Start: i.Start, End: i.End}
+	if other.Start > result.Start {
+		result.Start = other.Start
+	}
+	if other.End < result.End {
+		result.End = other.End
+	}
+	return result, true
+}
+
+// Merge returns the sorted union of the intervals, overlapping and adjacent intervals are joined.
+func Merge(intervals []Interval) []Interval {
+	if len(intervals) == 0 {
+		return nil
+	}
+
+	sorted := make([]Interval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Start < sorted[b].Start
+	})
+
+	result := []Interval{sorted[0]}
+	for _, interval := range sorted[1:] {
+		last := &result[len(result)-1]
+		if interval.Start <= last.End {
+			if interval.End > last.End {
+				last.End = interval.End
+			}
+			continue
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+func TotalMinutes(intervals []Interval) int {
+	total := 0
+	for _, interval := range Merge(intervals) {
+		total += interval.Minutes()
+	}
+	return total
+}
+
+func Strings(intervals []Interval) []string {
+	result := make([]string, len(intervals))
+	for i, interval := range intervals {
+		result[i] = interval.String()
+	}
+	return result
+}
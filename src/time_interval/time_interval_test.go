@@ -0,0 +1,42 @@
+package time_interval
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	interval, err := Parse("09:30-12:00")
+	require.NoError(t, err)
+	require.Equal(t, Interval{Start: 570, End: 720}, interval)
+	require.Equal(t, 150, interval.Minutes())
+	require.Equal(t, "09:30-12:00", interval.String())
+
+	_, err = Parse("12:00-09:30")
+	require.Error(t, err, "interval must end after it starts")
+
+	_, err = Parse("12:00")
+	require.Error(t, err, "interval without end must fail")
+}
+
+func TestMerge(t *testing.T) {
+	intervals, err := ParseAll([]string{"14:00-16:00", "09:00-11:00", "10:00-12:00", "16:00-17:00"})
+	require.NoError(t, err)
+
+	merged := Merge(intervals)
+	require.Equal(t, []string{"09:00-12:00", "14:00-17:00"}, Strings(merged))
+	require.Equal(t, 360, TotalMinutes(intervals))
+}
+
+func TestIntersect(t *testing.T) {
+	a, _ := Parse("09:00-12:00")
+	b, _ := Parse("11:00-13:00")
+	c, _ := Parse("12:00-13:00")
+
+	common, ok := a.Intersect(b)
+	require.True(t, ok)
+	require.Equal(t, "11:00-12:00", common.String())
+
+	_, ok = a.Intersect(c)
+	require.False(t, ok, "touching intervals do not overlap")
+}
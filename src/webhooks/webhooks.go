@@ -0,0 +1,127 @@
+// Package webhooks sends signed event deliveries to subscribers and decides when failed ones are retried.
+package webhooks
+
+import (
+	"Ya.SumSchool23/services/model"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of a delivery. The signature is "sha256=" and the hex HMAC-SHA256 of the body keyed by the webhook secret.
+const (
+	SignatureHeader  = "X-Signature-256"
+	EventIdHeader    = "X-Event-Id"
+	EventTypeHeader  = "X-Event-Type"
+	DeliveryIdHeader = "X-Delivery-Id"
+)
+
+const signaturePrefix = "sha256="
+
+// maxErrorBody is how much of a failed response is kept as the delivery error.
+const maxErrorBody = 512
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a received delivery in constant time.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// RetryPolicy retries a failed delivery after BaseBackoff doubled with every attempt up to MaxBackoff,
+// a delivery failed MaxAttempts times is dead.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Backoff is the wait after the failed attempt, attempts are counted from 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// Outcome is the state of a delivery after an attempt.
+type Outcome struct {
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      *string
+}
+
+type Sender struct {
+	client *http.Client
+	policy RetryPolicy
+}
+
+func NewSender(timeout time.Duration, policy RetryPolicy) *Sender {
+	return &Sender{
+		client: &http.Client{Timeout: timeout},
+		policy: policy,
+	}
+}
+
+// Deliver posts the delivery to the webhook once, any status but 2xx is a failure.
+func (s *Sender) Deliver(webhook *model.Webhook, delivery *model.WebhookDelivery, now time.Time) Outcome {
+	outcome := Outcome{Attempts: delivery.Attempts + 1, NextAttemptAt: now}
+
+	statusCode, err := s.post(webhook, delivery)
+	if statusCode != 0 {
+		outcome.LastStatusCode = &statusCode
+	}
+	if err == nil {
+		outcome.Status = model.DeliveryDelivered
+		return outcome
+	}
+
+	message := err.Error()
+	outcome.LastError = &message
+	if outcome.Attempts >= s.policy.MaxAttempts {
+		outcome.Status = model.DeliveryDead
+		return outcome
+	}
+	outcome.Status = model.DeliveryPending
+	outcome.NextAttemptAt = now.Add(s.policy.Backoff(outcome.Attempts))
+	return outcome
+}
+
+func (s *Sender) post(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, delivery.Body))
+	request.Header.Set(EventIdHeader, strconv.FormatInt(delivery.EventId, 10))
+	request.Header.Set(EventTypeHeader, delivery.EventType)
+	request.Header.Set(DeliveryIdHeader, strconv.FormatInt(delivery.DeliveryId, 10))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("status %d: %s", response.StatusCode, string(body))
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,82 @@
+package webhooks
+
+import (
+	"Ya.SumSchool23/services/model"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var policy = RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Second, policy.Backoff(1))
+	require.Equal(t, 2*time.Second, policy.Backoff(2))
+	require.Equal(t, 8*time.Second, policy.Backoff(4))
+	require.Equal(t, 10*time.Second, policy.Backoff(5))
+	require.Equal(t, 10*time.Second, policy.Backoff(60))
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signature := Sign("secret", body)
+	require.True(t, Verify("secret", body, signature))
+	require.False(t, Verify("other", body, signature))
+	require.False(t, Verify("secret", []byte(`{"id":2}`), signature))
+}
+
+func TestDeliver(t *testing.T) {
+	status := http.StatusOK
+	webhook := &model.Webhook{WebhookId: 1, Secret: "secret"}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.True(t, Verify(webhook.Secret, body, r.Header.Get(SignatureHeader)))
+		require.Equal(t, "7", r.Header.Get(EventIdHeader))
+		require.Equal(t, model.EventOrderCompleted, r.Header.Get(EventTypeHeader))
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+	webhook.Url = receiver.URL
+
+	sender := NewSender(time.Second, policy)
+	now := time.Date(2023, 5, 11, 10, 0, 0, 0, time.UTC)
+	delivery := &model.WebhookDelivery{DeliveryId: 3, WebhookId: 1, EventId: 7, EventType: model.EventOrderCompleted, Body: []byte(`{"id":7}`)}
+
+	outcome := sender.Deliver(webhook, delivery, now)
+	require.Equal(t, model.DeliveryDelivered, outcome.Status)
+	require.Equal(t, 1, outcome.Attempts)
+	require.Equal(t, http.StatusOK, *outcome.LastStatusCode)
+
+	status = http.StatusInternalServerError
+	outcome = sender.Deliver(webhook, delivery, now)
+	require.Equal(t, model.DeliveryPending, outcome.Status)
+	require.Equal(t, now.Add(time.Second), outcome.NextAttemptAt)
+	require.Equal(t, http.StatusInternalServerError, *outcome.LastStatusCode)
+	require.NotNil(t, outcome.LastError)
+
+	delivery.Attempts = 1
+	outcome = sender.Deliver(webhook, delivery, now)
+	require.Equal(t, model.DeliveryPending, outcome.Status)
+	require.Equal(t, now.Add(2*time.Second), outcome.NextAttemptAt)
+
+	delivery.Attempts = 2
+	outcome = sender.Deliver(webhook, delivery, now)
+	require.Equal(t, model.DeliveryDead, outcome.Status)
+	require.Equal(t, 3, outcome.Attempts)
+}
+
+func TestDeliverToUnreachableReceiver(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := receiver.URL
+	receiver.Close()
+
+	outcome := NewSender(time.Second, policy).Deliver(&model.Webhook{Url: url, Secret: "secret"},
+		&model.WebhookDelivery{Body: []byte(`{}`)}, time.Now())
+	require.Equal(t, model.DeliveryPending, outcome.Status)
+	require.Nil(t, outcome.LastStatusCode)
+	require.NotNil(t, outcome.LastError)
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -9,7 +10,9 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 var apiUrl string
@@ -35,6 +38,7 @@ func TestPingHandler(t *testing.T) {
 }
 
 func TestPostOrdersAndPostOrdersComplete(t *testing.T) {
+	ensureRegion(t, 2)
 	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 2, "delivery_hours": ["13:14-15:16"], "cost": 5}]}`))
 	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
 	require.NoError(t, err, "HTTP error")
@@ -56,6 +60,7 @@ func TestPostOrdersAndPostOrdersComplete(t *testing.T) {
 }
 
 func TestPostOrdersComplete(t *testing.T) {
+	ensureRegion(t, 3)
 	postOrderRequest := bytes.NewReader([]byte(`{"orders": [{"weight": 6, "regions": 3, "delivery_hours": ["16:16-17:17"], "cost": 10}]}`))
 	postOrderResponse, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", postOrderRequest)
 	if err != nil {
@@ -98,6 +103,7 @@ func TestPostOrdersComplete(t *testing.T) {
 }
 
 func TestPostCouriers(t *testing.T) {
+	ensureRegion(t, 5)
 	r := bytes.NewReader([]byte(`{"couriers":[{"courier_type": "AUTO","regions": [5], "working_hours": ["16:18-20:21"]}]}`))
 	resp, err := http.Post(fmt.Sprintf("%s/couriers", apiUrl), "application/json", r)
 	require.NoError(t, err, "HTTP error")
@@ -118,6 +124,128 @@ func TestPostCouriers(t *testing.T) {
 	require.Equal(t, []string{"16:18-20:21"}, couriers[0].WorkingHours)
 }
 
+func TestDeactivateAndActivateCourier(t *testing.T) {
+	ensureRegion(t, 7)
+	r := bytes.NewReader([]byte(`{"couriers":[{"courier_type": "FOOT","regions": [7], "working_hours": ["10:00-12:00"]}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/couriers", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "failed to read HTTP body")
+
+	created := new(PostCouriersResponse)
+	err = json.Unmarshal(body, &created)
+	require.NoError(t, err, "cannot unmarshal post couriers response")
+	require.True(t, created.Couriers[0].Active)
+
+	id := created.Couriers[0].CourierId
+	respDeactivate, err := http.Post(fmt.Sprintf("%s/couriers/%d/deactivate?archive=true", apiUrl, id), "application/json", nil)
+	require.NoError(t, err, "HTTP error")
+	defer respDeactivate.Body.Close()
+	require.Equal(t, http.StatusOK, respDeactivate.StatusCode, "HTTP status code")
+
+	deactivated := new(CourierDto)
+	body, err = io.ReadAll(respDeactivate.Body)
+	require.NoError(t, err, "failed to read HTTP body")
+	err = json.Unmarshal(body, deactivated)
+	require.NoError(t, err, "cannot unmarshal deactivate response")
+	require.False(t, deactivated.Active)
+	require.True(t, deactivated.Archived)
+
+	respActivate, err := http.Post(fmt.Sprintf("%s/couriers/%d/activate", apiUrl, id), "application/json", nil)
+	require.NoError(t, err, "HTTP error")
+	defer respActivate.Body.Close()
+	require.Equal(t, http.StatusOK, respActivate.StatusCode, "HTTP status code")
+
+	activated := new(CourierDto)
+	body, err = io.ReadAll(respActivate.Body)
+	require.NoError(t, err, "failed to read HTTP body")
+	err = json.Unmarshal(body, activated)
+	require.NoError(t, err, "cannot unmarshal activate response")
+	require.True(t, activated.Active)
+	require.False(t, activated.Archived)
+}
+
+func TestPostOrdersWithUnknownRegion(t *testing.T) {
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 99999, "delivery_hours": ["13:14-15:16"], "cost": 5}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestOrdersAssignWithUnknownStrategy(t *testing.T) {
+	resp, err := http.Post(fmt.Sprintf("%s/orders/assign?strategy=unknown", apiUrl), "application/json", nil)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestOrdersAssignDryRunWithWhatIf(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{
+		"couriers": [{"courier_type": "BIKE", "regions": [11], "working_hours": ["09:00-18:00"]}],
+		"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100}]
+	}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders/assign?dry_run=true&date=2030-01-01", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "failed to read HTTP body")
+
+	response := make([]OrderAssignResponse, 0)
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err, "cannot unmarshal assign response")
+	require.Equal(t, 1, len(response))
+	require.NotNil(t, response[0].Metrics)
+
+	planned := false
+	for _, courier := range response[0].Couriers {
+		for _, group := range courier.Orders {
+			for _, order := range group.Orders {
+				planned = planned || order.OrderId == -1
+			}
+		}
+	}
+	require.True(t, planned, "hypothetical order must be planned")
+
+	respAssignments, err := http.Get(fmt.Sprintf("%s/couriers/assignments?date=2030-01-01", apiUrl))
+	require.NoError(t, err, "HTTP error")
+	defer respAssignments.Body.Close()
+	require.Equal(t, http.StatusOK, respAssignments.StatusCode, "HTTP status code")
+}
+
+func TestAssignToCourierWithUnknownOrder(t *testing.T) {
+	resp, err := http.Post(fmt.Sprintf("%s/orders/999999/assign-to/1", apiUrl), "application/json", nil)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, "HTTP status code")
+}
+
+// ensureRegion creates the region if it does not exist yet.
+func ensureRegion(t *testing.T, id int64) {
+	resp, err := http.Get(fmt.Sprintf("%s/regions/%d", apiUrl, id))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return
+	}
+
+	str := fmt.Sprintf(`{"region_id": %d, "name": "Region %d", "time_zone": "Europe/Moscow"}`, id, id)
+	respCreate, err := http.Post(fmt.Sprintf("%s/regions", apiUrl), "application/json", bytes.NewReader([]byte(str)))
+	require.NoError(t, err, "HTTP error")
+	defer respCreate.Body.Close()
+
+	require.Equal(t, http.StatusOK, respCreate.StatusCode, "HTTP status code")
+}
+
 type PostCouriersResponse struct {
 	Couriers []CourierDto `json:"couriers"`
 }
@@ -127,6 +255,8 @@ type CourierDto struct {
 	CourierType  string   `json:"courier_type"`
 	Regions      []int64  `json:"regions"`
 	WorkingHours []string `json:"working_hours"`
+	Active       bool     `json:"active"`
+	Archived     bool     `json:"archived"`
 }
 
 type OrderDto struct {
@@ -137,3 +267,575 @@ type OrderDto struct {
 	Cost          int64    `json:"cost"`
 	CompletedTime *string  `json:"completed_time"`
 }
+
+type OrderAssignResponse struct {
+	Date     string `json:"date"`
+	Couriers []struct {
+		CourierId int64 `json:"courier_id"`
+		Orders    []struct {
+			GroupOrderId int64      `json:"group_order_id"`
+			Orders       []OrderDto `json:"orders"`
+		} `json:"orders"`
+	} `json:"couriers"`
+	Strategy string `json:"strategy"`
+	Metrics  *struct {
+		Assigned   int `json:"assigned"`
+		Unassigned int `json:"unassigned"`
+	} `json:"metrics"`
+}
+
+func TestPostOrdersWithInvalidPromisedBy(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100, "promised_by": "tomorrow"}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestOrdersLateRates(t *testing.T) {
+	resp, err := http.Get(fmt.Sprintf("%s/orders/late-rates?startDate=2023-05-01&endDate=2023-06-01", apiUrl))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+}
+
+func TestPostOrdersForPastDeliveryDate(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100, "delivery_date": "2020-01-01"}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestGetOrdersByDeliveryDate(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100, "delivery_date": "2030-01-02"}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	resp, err = http.Get(fmt.Sprintf("%s/orders?delivery_date=2030-01-02&limit=100", apiUrl))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var orders []struct {
+		DeliveryDate string `json:"delivery_date"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	require.NotEmpty(t, orders)
+	for _, order := range orders {
+		require.Equal(t, "2030-01-02", order.DeliveryDate)
+	}
+}
+
+func TestPostOrdersPricedByTariff(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"base_price": 100, "zone_prices": [{"region_id": 11, "base_price": 150}], "per_kg_price": 10,
+		"narrow_window_minutes": 60, "narrow_window_surcharge": 50, "surges": [{"interval": "08:00-10:00", "multiplier": 1.5}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/tariffs", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	var tariff struct {
+		Version int64 `json:"version"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tariff))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	r = bytes.NewReader([]byte(`{"orders": [{"weight": 2, "regions": 11, "delivery_hours": ["09:00-09:30"]}]}`))
+	resp, err = http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var orders []struct {
+		Cost          int64  `json:"cost"`
+		TariffVersion *int64 `json:"tariff_version"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	require.Len(t, orders, 1)
+	require.Equal(t, int64(330), orders[0].Cost, "(150 + 2 * 10 + 50) * 1.5")
+	require.Equal(t, &tariff.Version, orders[0].TariffVersion)
+}
+
+func TestPostTariffWithOverlappingSurges(t *testing.T) {
+	r := bytes.NewReader([]byte(`{"base_price": 100, "per_kg_price": 10,
+		"surges": [{"interval": "08:00-10:00", "multiplier": 1.5}, {"interval": "09:00-11:00", "multiplier": 2}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/tariffs", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestClosePeriodNotOver(t *testing.T) {
+	r := bytes.NewReader([]byte(`{"from": "2030-01-01", "to": "2030-02-01"}`))
+	resp, err := http.Post(fmt.Sprintf("%s/payouts/close-period", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestLedgerOfUnknownCourier(t *testing.T) {
+	resp, err := http.Get(fmt.Sprintf("%s/couriers/999999/ledger", apiUrl))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, "HTTP status code")
+}
+
+func TestCourierStatementWithUnknownFormat(t *testing.T) {
+	resp, err := http.Get(fmt.Sprintf("%s/couriers/1/statement?from=2023-05-01&to=2023-06-01&format=pdf", apiUrl))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestFeedbackOnNotCompletedOrder(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	var orders []OrderDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	resp.Body.Close()
+	require.Len(t, orders, 1)
+
+	r = bytes.NewReader([]byte(`{"score": 5, "comment": "fast"}`))
+	resp, err = http.Post(fmt.Sprintf("%s/orders/%d/feedback", apiUrl, orders[0].OrderId), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestOrderHistory(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100}]}`))
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/orders", apiUrl), r)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Actor", "dispatcher")
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err, "HTTP error")
+	var orders []OrderDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	resp.Body.Close()
+	require.Len(t, orders, 1)
+
+	resp, err = http.Get(fmt.Sprintf("%s/orders/%d/history", apiUrl, orders[0].OrderId))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var events []struct {
+		Type  string `json:"type"`
+		Actor string `json:"actor"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+	require.NotEmpty(t, events)
+	require.Equal(t, "created", events[0].Type)
+	require.Equal(t, "dispatcher", events[0].Actor)
+}
+
+func TestAuditOfCourierDeactivation(t *testing.T) {
+	ensureRegion(t, 7)
+	r := bytes.NewReader([]byte(`{"couriers":[{"courier_type": "FOOT","regions": [7], "working_hours": ["10:00-12:00"]}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/couriers", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	created := new(PostCouriersResponse)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(created))
+	resp.Body.Close()
+	id := created.Couriers[0].CourierId
+
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/couriers/%d/deactivate", apiUrl, id), nil)
+	require.NoError(t, err)
+	request.Header.Set("X-Actor", "auditor")
+	request.Header.Set("X-Request-ID", fmt.Sprintf("deactivate-%d", id))
+	resp, err = http.DefaultClient.Do(request)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	resp, err = http.Get(fmt.Sprintf("%s/audit?actor=auditor&entity_type=courier&entity_id=%d&limit=10", apiUrl, id))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var entries []struct {
+		Route     string                     `json:"route"`
+		RequestId string                     `json:"request_id"`
+		Status    int                        `json:"status"`
+		Diff      map[string]json.RawMessage `json:"diff"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+	require.Len(t, entries, 1)
+	require.Equal(t, "/couriers/:courier_id/deactivate", entries[0].Route)
+	require.Equal(t, fmt.Sprintf("deactivate-%d", id), entries[0].RequestId)
+	require.Equal(t, http.StatusOK, entries[0].Status)
+	require.Contains(t, entries[0].Diff, "Active")
+}
+
+func TestWebhookWithUnknownEventType(t *testing.T) {
+	r := bytes.NewReader([]byte(`{"url": "http://localhost:9999/hook", "event_types": ["OrderLost"]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/webhooks", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "HTTP status code")
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	r := bytes.NewReader([]byte(`{"url": "http://localhost:9999/hook", "event_types": ["OrderCompleted"]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/webhooks", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	var webhook struct {
+		WebhookId int64  `json:"webhook_id"`
+		Secret    string `json:"secret"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&webhook))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+	require.NotEmpty(t, webhook.Secret)
+
+	resp, err = http.Get(fmt.Sprintf("%s/webhooks/%d/deliveries?status=dead&limit=10", apiUrl, webhook.WebhookId))
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/webhooks/%d", apiUrl, webhook.WebhookId), nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(request)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	resp, err = http.Get(fmt.Sprintf("%s/webhooks/%d/deliveries", apiUrl, webhook.WebhookId))
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, "HTTP status code")
+}
+
+func TestEventStreamResume(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 100}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/events/stream?region=11", apiUrl), nil)
+	require.NoError(t, err)
+	request.Header.Set("Last-Event-ID", "0")
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err = client.Do(request)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	require.True(t, lines.Scan())
+	require.True(t, strings.HasPrefix(lines.Text(), "id: "), lines.Text())
+	require.True(t, lines.Scan())
+	require.True(t, strings.HasPrefix(lines.Text(), "event: "), lines.Text())
+}
+
+func TestLedgerOfCompletedOrders(t *testing.T) {
+	ensureRegion(t, 13)
+	courierId := createCourier(t, "FOOT", 13)
+	first := createOrder(t, 13, 100)
+	second := createOrder(t, 13, 50)
+
+	// a past day of its own, closed periods cannot overlap
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(time.Now().UnixNano()%7000))
+	completeOrder(t, courierId, first, day.Add(8*time.Hour))
+
+	ledger := getCourierLedger(t, courierId)
+	require.Len(t, ledger.Entries, 1)
+	require.Equal(t, "earning", ledger.Entries[0].Kind)
+	require.Equal(t, first, *ledger.Entries[0].OrderId)
+	require.Equal(t, int64(200), ledger.Entries[0].Amount, "FOOT earns twice the cost")
+	require.Equal(t, day.Format("2006-01-02"), ledger.Entries[0].Date)
+	require.Nil(t, ledger.Entries[0].PeriodId)
+	require.Equal(t, int64(200), ledger.Balance)
+
+	str := fmt.Sprintf(`{"from": "%s", "to": "%s"}`, day.Format("2006-01-02"), day.AddDate(0, 0, 1).Format("2006-01-02"))
+	resp, err := http.Post(fmt.Sprintf("%s/payouts/close-period", apiUrl), "application/json", bytes.NewReader([]byte(str)))
+	require.NoError(t, err, "HTTP error")
+	period := new(PayoutPeriodDto)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(period))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var payout *LedgerEntryDto
+	for i := range period.Payouts {
+		if period.Payouts[i].CourierId == courierId {
+			payout = &period.Payouts[i]
+		}
+	}
+	require.NotNil(t, payout, "the balance of the period must be paid out")
+	require.Equal(t, int64(-200), payout.Amount)
+	require.Equal(t, day.Format("2006-01-02"), payout.Date)
+
+	ledger = getCourierLedger(t, courierId)
+	require.Len(t, ledger.Entries, 2)
+	for _, entry := range ledger.Entries {
+		require.NotNil(t, entry.PeriodId, "entries of a closed period are frozen")
+		require.Equal(t, period.PeriodId, *entry.PeriodId)
+	}
+	require.Equal(t, int64(0), ledger.Balance)
+
+	// the period is paid out already, the late completion is booked today
+	completeOrder(t, courierId, second, day.Add(9*time.Hour))
+
+	ledger = getCourierLedger(t, courierId)
+	require.Len(t, ledger.Entries, 3)
+	require.Equal(t, "earning", ledger.Entries[0].Kind)
+	require.Equal(t, second, *ledger.Entries[0].OrderId)
+	require.Equal(t, time.Now().UTC().Format("2006-01-02"), ledger.Entries[0].Date)
+	require.Nil(t, ledger.Entries[0].PeriodId)
+	require.Equal(t, int64(100), ledger.Balance)
+}
+
+// createCourier creates a courier working in the region from 09:00 to 18:00.
+func createCourier(t *testing.T, courierType string, region int64) int64 {
+	str := fmt.Sprintf(`{"couriers":[{"courier_type": "%s","regions": [%d], "working_hours": ["09:00-18:00"]}]}`, courierType, region)
+	resp, err := http.Post(fmt.Sprintf("%s/couriers", apiUrl), "application/json", bytes.NewReader([]byte(str)))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	created := new(PostCouriersResponse)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(created))
+	require.Len(t, created.Couriers, 1)
+	return created.Couriers[0].CourierId
+}
+
+// createOrder creates an order of the region delivered today from 10:00 to 12:00.
+func createOrder(t *testing.T, region, cost int64) int64 {
+	str := fmt.Sprintf(`{"orders": [{"weight": 1, "regions": %d, "delivery_hours": ["10:00-12:00"], "cost": %d}]}`, region, cost)
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", bytes.NewReader([]byte(str)))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var orders []OrderDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	require.Len(t, orders, 1)
+	return orders[0].OrderId
+}
+
+func completeOrder(t *testing.T, courierId, orderId int64, completed time.Time) {
+	str := fmt.Sprintf(`{"complete_info": [{"courier_id": %d, "order_id": %d, "complete_time": "%s"}]}`,
+		courierId, orderId, completed.UTC().Format(time.RFC3339))
+	resp, err := http.Post(fmt.Sprintf("%s/orders/complete", apiUrl), "application/json", bytes.NewReader([]byte(str)))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+}
+
+func getCourierLedger(t *testing.T, courierId int64) *CourierLedgerResponse {
+	resp, err := http.Get(fmt.Sprintf("%s/couriers/%d/ledger?limit=100", apiUrl, courierId))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	ledger := new(CourierLedgerResponse)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(ledger))
+	return ledger
+}
+
+type CourierLedgerResponse struct {
+	CourierId int64            `json:"courier_id"`
+	Balance   int64            `json:"balance"`
+	Entries   []LedgerEntryDto `json:"entries"`
+}
+
+type LedgerEntryDto struct {
+	EntryId   int64  `json:"entry_id"`
+	CourierId int64  `json:"courier_id"`
+	Kind      string `json:"kind"`
+	Amount    int64  `json:"amount"`
+	OrderId   *int64 `json:"order_id"`
+	Date      string `json:"date"`
+	PeriodId  *int64 `json:"period_id"`
+}
+
+type PayoutPeriodDto struct {
+	PeriodId int64            `json:"period_id"`
+	Payouts  []LedgerEntryDto `json:"payouts"`
+}
+
+func TestCourierStatementContent(t *testing.T) {
+	ensureRegion(t, 17)
+	courierId := createCourier(t, "FOOT", 17)
+	first := createOrder(t, 17, 100)
+	second := createOrder(t, 17, 100)
+
+	// both orders go into one group order, the second delivered gets 20% off
+	var groupOrderIds []int64
+	for _, orderId := range []int64{first, second} {
+		resp, err := http.Post(fmt.Sprintf("%s/orders/%d/assign-to/%d", apiUrl, orderId, courierId), "application/json", nil)
+		require.NoError(t, err, "HTTP error")
+		var override struct {
+			GroupOrderId int64 `json:"group_order_id"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&override))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+		groupOrderIds = append(groupOrderIds, override.GroupOrderId)
+	}
+	require.Equal(t, groupOrderIds[0], groupOrderIds[1], "orders must be grouped")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	completeOrder(t, courierId, first, today.Add(8*time.Hour))
+	completeOrder(t, courierId, second, today.Add(8*time.Hour))
+
+	for _, str := range []string{
+		`{"kind": "bonus", "amount": 30}`,
+		`{"kind": "penalty", "reason": "other", "amount": 10, "comment": "damaged, box"}`,
+	} {
+		resp, err := http.Post(fmt.Sprintf("%s/couriers/%d/ledger", apiUrl, courierId), "application/json", bytes.NewReader([]byte(str)))
+		require.NoError(t, err, "HTTP error")
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+	}
+
+	url := fmt.Sprintf("%s/couriers/%d/statement?from=%s&to=%s", apiUrl, courierId,
+		today.Format("2006-01-02"), today.AddDate(0, 0, 1).Format("2006-01-02"))
+	csv := getStatement(t, url+"&format=csv")
+	require.Contains(t, csv, fmt.Sprintf("\n%d,FOOT,%s,%s\n", courierId, today.Format("2006-01-02"), today.AddDate(0, 0, 1).Format("2006-01-02")))
+	require.Regexp(t, `\n\d+,[^,]+,100,0,100,200\n`, csv, "the first order of the group is paid in full")
+	require.Regexp(t, `\n\d+,[^,]+,100,20,80,160\n`, csv, "the second order of the group is discounted")
+	require.Regexp(t, `\n\d+,\d{4}-\d{2}-\d{2},bonus,,,30,\n`, csv)
+	require.Regexp(t, `\n\d+,\d{4}-\d{2}-\d{2},penalty,other,,-10,"damaged, box"\n`, csv)
+	require.Contains(t, csv, "\n360,30,-10,380,0,")
+
+	html := getStatement(t, url+"&format=html")
+	require.Contains(t, html, fmt.Sprintf("Statement of courier %d (FOOT)", courierId))
+	require.Contains(t, html, `<td class="number">100</td><td class="number">20</td><td class="number">80</td><td class="number">160</td>`)
+	require.Contains(t, html, `<td>penalty</td><td>other</td><td></td><td class="number">-10</td><td>damaged, box</td>`)
+	require.Contains(t, html, `<tr><th>Earnings</th><td class="number">360</td></tr>`)
+	require.Contains(t, html, `<tr><th>Total</th><td class="number">380</td></tr>`)
+}
+
+func getStatement(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "failed to read HTTP body")
+	return string(body)
+}
+
+func TestFeedbackOnCompletedOrder(t *testing.T) {
+	ensureRegion(t, 19)
+	courierId := createCourier(t, "BIKE", 19)
+	orderId := createOrder(t, 19, 100)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	completeOrder(t, courierId, orderId, today.Add(8*time.Hour))
+
+	r := bytes.NewReader([]byte(`{"score": 4, "comment": "fast"}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders/%d/feedback", apiUrl, orderId), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	r = bytes.NewReader([]byte(`{"score": 1, "comment": "changed my mind"}`))
+	resp, err = http.Post(fmt.Sprintf("%s/orders/%d/feedback", apiUrl, orderId), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, "an order gets feedback once")
+
+	resp, err = http.Get(fmt.Sprintf("%s/couriers/meta-info/%d?startDate=%s&endDate=%s", apiUrl, courierId,
+		today.Format("2006-01-02"), today.AddDate(0, 0, 1).Format("2006-01-02")))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var metaInfo struct {
+		Rating   *int64   `json:"rating"`
+		Earnings *int64   `json:"earnings"`
+		Quality  *float64 `json:"quality"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&metaInfo))
+	require.NotNil(t, metaInfo.Quality)
+	require.Equal(t, float64(4), *metaInfo.Quality, "the second feedback is not counted")
+	require.NotNil(t, metaInfo.Earnings)
+	require.Equal(t, int64(300), *metaInfo.Earnings, "BIKE earns three times the cost")
+	require.NotNil(t, metaInfo.Rating)
+}
+
+func TestPostOrdersWithZeroCost(t *testing.T) {
+	ensureRegion(t, 11)
+	r := bytes.NewReader([]byte(`{"orders": [{"weight": 1, "regions": 11, "delivery_hours": ["10:00-12:00"], "cost": 0}]}`))
+	resp, err := http.Post(fmt.Sprintf("%s/orders", apiUrl), "application/json", r)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	var orders []struct {
+		Cost          int64  `json:"cost"`
+		TariffVersion *int64 `json:"tariff_version"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	require.Len(t, orders, 1)
+	require.Equal(t, int64(0), orders[0].Cost, "an explicit cost is not priced by the tariff")
+	require.Nil(t, orders[0].TariffVersion)
+}
+
+func TestAssignToCourierForcedByNonAdmin(t *testing.T) {
+	ensureRegion(t, 11)
+	courierId := createCourier(t, "FOOT", 11)
+	orderId := createOrder(t, 11, 100)
+
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/orders/%d/assign-to/%d?force=true", apiUrl, orderId, courierId), nil)
+	require.NoError(t, err)
+	request.Header.Set("X-Actor", "admin")
+	request.Header.Set("X-Admin-Token", "guessed")
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "HTTP status code")
+}
+
+func TestEventStreamOfCourierDeactivation(t *testing.T) {
+	ensureRegion(t, 23)
+	courierId := createCourier(t, "FOOT", 23)
+	orderId := createOrder(t, 23, 100)
+	resp, err := http.Post(fmt.Sprintf("%s/orders/%d/assign-to/%d", apiUrl, orderId, courierId), "application/json", nil)
+	require.NoError(t, err, "HTTP error")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err = client.Get(fmt.Sprintf("%s/events/stream?courier_id=%d", apiUrl, courierId))
+	require.NoError(t, err, "HTTP error")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "HTTP status code")
+
+	respDeactivate, err := http.Post(fmt.Sprintf("%s/couriers/%d/deactivate", apiUrl, courierId), "application/json", nil)
+	require.NoError(t, err, "HTTP error")
+	respDeactivate.Body.Close()
+	require.Equal(t, http.StatusOK, respDeactivate.StatusCode, "HTTP status code")
+
+	var types []string
+	lines := bufio.NewScanner(resp.Body)
+	for len(types) < 2 && lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "event: ") {
+			types = append(types, strings.TrimPrefix(lines.Text(), "event: "))
+		}
+	}
+	require.Equal(t, []string{"CourierUpdated", "OrderUnassigned"}, types)
+}